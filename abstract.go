@@ -0,0 +1,27 @@
+package bibtex
+
+import (
+	"regexp"
+	"strings"
+)
+
+// paragraphBreakRe matches a blank line separating paragraphs.
+var paragraphBreakRe = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// AbstractText returns the abstract field of the entry as plain text. LaTeX
+// markup is decoded and whitespace is collapsed within paragraphs, while
+// paragraph breaks, written as \par, \\ or a blank line, are kept as "\n\n".
+func (entry *BibEntry) AbstractText() string {
+	value, ok := entry.Fields["abstract"]
+	if !ok {
+		return ""
+	}
+	d := &latexDecoder{s: []rune(value.String()), paragraphs: true}
+	var paras []string
+	for _, p := range paragraphBreakRe.Split(d.decode(false), -1) {
+		if p = collapseSpace(p); p != "" {
+			paras = append(paras, p)
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
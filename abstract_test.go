@@ -0,0 +1,22 @@
+package bibtex
+
+import "testing"
+
+func TestAbstractText(t *testing.T) {
+	entry := MustParse(t, `@article{a, abstract = {We study the  \emph{caf\'e}
+problem.\par Results for G{\"o}del
+numbering.
+
+A third   paragraph.\\ And a fourth.}}`).Entries[0]
+	expect := "We study the café problem.\n\nResults for Gödel numbering.\n\nA third paragraph.\n\nAnd a fourth."
+	if got := entry.AbstractText(); got != expect {
+		t.Errorf("got abstract %q, expected %q", got, expect)
+	}
+}
+
+func TestAbstractTextMissing(t *testing.T) {
+	entry := MustParse(t, `@article{a, title = {T}}`).Entries[0]
+	if got := entry.AbstractText(); got != "" {
+		t.Errorf("got abstract %q, expected empty", got)
+	}
+}
@@ -0,0 +1,85 @@
+package bibtex
+
+import "strings"
+
+// FileRef is a file attached to an entry, in the file field as written by
+// JabRef.
+type FileRef struct {
+	Description string
+	Path        string
+	Type        string // File type, such as PDF.
+}
+
+// Files parses the file field of the entry. The field holds semicolon
+// separated description:path:type triples, in which colons, semicolons and
+// backslashes are escaped with a backslash. A reference with a single part is
+// taken as a path. The field name is matched ignoring case.
+func (entry *BibEntry) Files() []FileRef {
+	value, ok := entry.Get("file")
+	if !ok {
+		return nil
+	}
+	var files []FileRef
+	for _, ref := range splitEscaped(strings.TrimSpace(value.String()), ';', false) {
+		if ref == "" {
+			continue
+		}
+		parts := splitEscaped(ref, ':', true)
+		var f FileRef
+		switch len(parts) {
+		case 1:
+			f.Path = parts[0]
+		case 2:
+			f.Description, f.Path = parts[0], parts[1]
+		default:
+			f.Description, f.Path, f.Type = parts[0], parts[1], strings.Join(parts[2:], ":")
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// SetFiles writes files to the file field of the entry, escaping them as
+// Files expects, replacing the field in any case such as File. The field is
+// removed if files is empty.
+func (entry *BibEntry) SetFiles(files []FileRef) {
+	entry.deleteField("file")
+	if len(files) == 0 {
+		return
+	}
+	escape := strings.NewReplacer(`\`, `\\`, ":", `\:`, ";", `\;`)
+	refs := make([]string, len(files))
+	for i, f := range files {
+		refs[i] = escape.Replace(f.Description) + ":" + escape.Replace(f.Path) + ":" + escape.Replace(f.Type)
+	}
+	entry.AddField("file", NewBibConst(strings.Join(refs, ";")))
+}
+
+// splitEscaped splits s at each sep not escaped by a backslash. If unescape
+// is set, the escapes of colons, semicolons and backslashes are removed.
+func splitEscaped(s string, sep rune, unescape bool) []string {
+	var parts []string
+	var buf strings.Builder
+	escaped := false
+	for _, ch := range s {
+		switch {
+		case escaped:
+			if !unescape || !strings.ContainsRune(`\:;`, ch) {
+				buf.WriteRune('\\')
+			}
+			buf.WriteRune(ch)
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == sep:
+			parts = append(parts, buf.String())
+			buf.Reset()
+		default:
+			buf.WriteRune(ch)
+		}
+	}
+	if escaped {
+		buf.WriteRune('\\')
+	}
+	return append(parts, buf.String())
+}
@@ -0,0 +1,52 @@
+package bibtex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	entry := MustParse(t, `@article{a, file = {Full text:papers/smith 2020.pdf:PDF;Slides:C\:\\talks\\smith\;v2.pptx:PowerPoint}}`).Entries[0]
+	expect := []FileRef{
+		{Description: "Full text", Path: "papers/smith 2020.pdf", Type: "PDF"},
+		{Description: "Slides", Path: `C:\talks\smith;v2.pptx`, Type: "PowerPoint"},
+	}
+	files := entry.Files()
+	if !reflect.DeepEqual(files, expect) {
+		t.Fatalf("got files %+v, expected %+v", files, expect)
+	}
+
+	entry.SetFiles(files)
+	if got := fieldString(entry, "file"); got != `Full text:papers/smith 2020.pdf:PDF;Slides:C\:\\talks\\smith\;v2.pptx:PowerPoint` {
+		t.Errorf("got file field %q", got)
+	}
+	if !reflect.DeepEqual(entry.Files(), expect) {
+		t.Errorf("files did not round trip: %+v", entry.Files())
+	}
+
+	entry.SetFiles(nil)
+	if _, ok := entry.Fields["file"]; ok || entry.Files() != nil {
+		t.Errorf("file field not removed")
+	}
+}
+
+func TestSetFilesReplacesCaseVariant(t *testing.T) {
+	entry := MustParse(t, `@article{a, File = {:old.pdf:PDF}}`).Entries[0]
+	if files := entry.Files(); len(files) != 1 || files[0].Path != "old.pdf" {
+		t.Fatalf("got files %+v", files)
+	}
+	entry.SetFiles([]FileRef{{Path: "new.pdf", Type: "PDF"}})
+	if names := entry.FieldNamesInOrder(); !reflect.DeepEqual(names, []string{"file"}) {
+		t.Errorf("got fields %v", names)
+	}
+	if files := entry.Files(); len(files) != 1 || files[0].Path != "new.pdf" {
+		t.Errorf("got files %+v", files)
+	}
+}
+
+func TestFilesPathOnly(t *testing.T) {
+	entry := MustParse(t, `@article{a, file = {papers/smith.pdf}}`).Entries[0]
+	if files := entry.Files(); len(files) != 1 || files[0] != (FileRef{Path: "papers/smith.pdf"}) {
+		t.Errorf("got files %+v", files)
+	}
+}
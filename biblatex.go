@@ -0,0 +1,164 @@
+package bibtex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// thesisTypes maps BibTeX thesis entry types to the biblatex type field of an
+// equivalent @thesis.
+var thesisTypes = map[string]string{
+	"phdthesis":     "phdthesis",
+	"mastersthesis": "mathesis",
+}
+
+// fieldNames maps biblatex field names to the BibTeX field names they replace.
+// The institution of a thesis is the school in BibTeX, and is handled
+// separately.
+var fieldNames = map[string]string{
+	"journaltitle": "journal",
+	"location":     "address",
+}
+
+// isoDateRe matches a biblatex date with year, and optionally month and day.
+var isoDateRe = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?$`)
+
+// ToBibLaTeX converts the entry from traditional BibTeX to biblatex
+// conventions, in place. A @phdthesis or @mastersthesis becomes a @thesis with
+// the type field set, unless it already has one. Fields are renamed, such as
+// journal to journaltitle, and the year, month and day are combined into a
+// date. Fields that would replace an existing field are left alone.
+func (entry *BibEntry) ToBibLaTeX() {
+	if typ, ok := thesisTypes[entry.Type]; ok {
+		entry.Type = "thesis"
+		if _, ok := entry.Get("type"); !ok {
+			entry.AddField("type", NewBibConst(typ))
+		}
+	}
+	if entry.Type == "thesis" {
+		entry.renameField("school", "institution")
+	}
+	for biblatex, bibtex := range fieldNames {
+		entry.renameField(bibtex, biblatex)
+	}
+	entry.toDate()
+}
+
+// toDate replaces the year, month and day fields with a date, if they are
+// numeric and there is no date already.
+func (entry *BibEntry) toDate() {
+	if _, ok := entry.Get("date"); ok {
+		return
+	}
+	year := fieldString(entry, "year")
+	if _, err := strconv.Atoi(year); err != nil || len(year) != 4 {
+		return
+	}
+	date := year
+	if _, ok := entry.Get("month"); ok {
+		month, ok := entry.Month()
+		if !ok {
+			return
+		}
+		date += fmt.Sprintf("-%02d", int(month))
+		if day := fieldString(entry, "day"); day != "" {
+			n, err := strconv.Atoi(day)
+			if err != nil || n < 1 || n > 31 {
+				return
+			}
+			date += fmt.Sprintf("-%02d", n)
+		}
+	}
+	entry.deleteField("year")
+	entry.deleteField("month")
+	entry.deleteField("day")
+	entry.AddField("date", NewBibConst(date))
+}
+
+// fromDate replaces a date with year, month and day fields, if it is a single
+// ISO 8601 date and none of those fields are present.
+func (entry *BibEntry) fromDate() {
+	m := isoDateRe.FindStringSubmatch(fieldString(entry, "date"))
+	if m == nil {
+		return
+	}
+	for _, field := range []string{"year", "month", "day"} {
+		if _, ok := entry.Get(field); ok {
+			return
+		}
+	}
+	month := 0
+	if m[2] != "" {
+		month, _ = strconv.Atoi(m[2])
+		if month < 1 || month > 12 {
+			return
+		}
+	}
+	entry.deleteField("date")
+	entry.AddField("year", NewBibConst(m[1]))
+	if month != 0 {
+		v, _ := monthVar(monthMacros[month-1])
+		entry.AddField("month", v)
+	}
+	if m[3] != "" {
+		entry.AddField("day", NewBibConst(strings.TrimLeft(m[3], "0")))
+	}
+}
+
+// renameField renames a field, unless the new name is already present.
+func (entry *BibEntry) renameField(from, to string) {
+	value, ok := entry.Get(from)
+	if !ok {
+		return
+	}
+	if _, ok := entry.Get(to); ok {
+		return
+	}
+	entry.deleteField(from)
+	entry.AddField(to, value)
+}
+
+// ToBibTeX converts the entry from biblatex to traditional BibTeX conventions,
+// in place, reversing ToBibLaTeX. A @thesis with a type field of phdthesis or
+// mathesis becomes a @phdthesis or @mastersthesis. Theses of other types are
+// left as @thesis. Fields are renamed, such as journaltitle to journal, and a
+// date is split into year, month and day.
+func (entry *BibEntry) ToBibTeX() {
+	for biblatex, bibtex := range fieldNames {
+		entry.renameField(biblatex, bibtex)
+	}
+	entry.fromDate()
+	if entry.Type != "thesis" {
+		return
+	}
+	entry.renameField("institution", "school")
+	typ, ok := entry.Get("type")
+	if !ok {
+		return
+	}
+	for bibtexType, biblatexType := range thesisTypes {
+		if strings.EqualFold(strings.TrimSpace(typ.String()), biblatexType) {
+			entry.Type = bibtexType
+			entry.deleteField("type")
+			return
+		}
+	}
+}
+
+// ToBibLaTeX converts all entries to biblatex conventions. See
+// BibEntry.ToBibLaTeX.
+func (bib *BibTex) ToBibLaTeX() {
+	for _, entry := range bib.Entries {
+		entry.ToBibLaTeX()
+	}
+}
+
+// ToBibTeX converts all entries to traditional BibTeX conventions. See
+// BibEntry.ToBibTeX.
+func (bib *BibTex) ToBibTeX() {
+	for _, entry := range bib.Entries {
+		entry.ToBibTeX()
+	}
+}
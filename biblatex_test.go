@@ -0,0 +1,91 @@
+package bibtex
+
+import "testing"
+
+func TestThesisConversion(t *testing.T) {
+	bib := MustParse(t, `
+@phdthesis{a, author = {Doe, Jane}, title = {T}, school = {MIT}, year = 2020}
+@mastersthesis{b, author = {Roe, R.}, title = {M}, school = {MIT}, year = 2019}
+@phdthesis{c, title = {H}, type = {Habilitation}}
+`)
+	bib.ToBibLaTeX()
+	expect := []struct{ Type, Subtype string }{
+		{"thesis", "phdthesis"},
+		{"thesis", "mathesis"},
+		{"thesis", "Habilitation"},
+	}
+	for i, e := range expect {
+		entry := bib.Entries[i]
+		typ, _ := entry.Get("type")
+		if entry.Type != e.Type || typ == nil || typ.String() != e.Subtype {
+			t.Errorf("%s: got @%s type = %v, expected @%s type = %s", entry.CiteName, entry.Type, typ, e.Type, e.Subtype)
+		}
+	}
+
+	bib.ToBibTeX()
+	for i, typ := range []string{"phdthesis", "mastersthesis", "thesis"} {
+		if got := bib.Entries[i].Type; got != typ {
+			t.Errorf("%s: got @%s, expected @%s", bib.Entries[i].CiteName, got, typ)
+		}
+	}
+	if _, ok := bib.Entries[0].Get("type"); ok {
+		t.Errorf("type field not removed")
+	}
+}
+
+func TestFieldConversion(t *testing.T) {
+	src := `@article{a, author = {Doe, Jane}, journaltitle = {J. Stuff}, date = {2020-05}, location = {Boston}, note = {N}}`
+	bib := MustParse(t, src)
+	bib.ToBibTeX()
+	entry := bib.Entries[0]
+	expect := map[string]string{
+		"author":  "Doe, Jane",
+		"journal": "J. Stuff",
+		"year":    "2020",
+		"month":   "May",
+		"address": "Boston",
+		"note":    "N",
+	}
+	AssertFields(t, entry, expect)
+	if v := entry.Fields["month"]; v.RawString() != "may" {
+		t.Errorf("got month %q, expected the may macro", v.RawString())
+	}
+
+	bib.ToBibLaTeX()
+	AssertFields(t, entry, map[string]string{
+		"author":       "Doe, Jane",
+		"journaltitle": "J. Stuff",
+		"date":         "2020-05",
+		"location":     "Boston",
+		"note":         "N",
+	})
+}
+
+func TestDateConversionFieldCase(t *testing.T) {
+	entry := MustParse(t, `@article{a, Year = {2020}, Month = mar, Day = {5}}`).Entries[0]
+	entry.ToBibLaTeX()
+	AssertFields(t, entry, map[string]string{"date": "2020-03-05"})
+}
+
+func TestFieldConversionThesis(t *testing.T) {
+	bib := MustParse(t, `@thesis{a, type = {phdthesis}, institution = {MIT}, date = {2019-01-31}}`)
+	bib.ToBibTeX()
+	entry := bib.Entries[0]
+	if entry.Type != "phdthesis" {
+		t.Errorf("got type %s", entry.Type)
+	}
+	AssertFields(t, entry, map[string]string{"school": "MIT", "year": "2019", "month": "January", "day": "31"})
+}
+
+// AssertFields checks the fields of entry are exactly those expected.
+func AssertFields(t *testing.T, entry *BibEntry, expect map[string]string) {
+	t.Helper()
+	if len(entry.Fields) != len(expect) {
+		t.Errorf("got fields %v, expected %v", entry.Fields, expect)
+	}
+	for key, value := range expect {
+		if got := fieldString(entry, key); got != value {
+			t.Errorf("field %s: got %q, expected %q", key, got, value)
+		}
+	}
+}
@@ -3,11 +3,9 @@ package bibtex
 import (
 	"bytes"
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 )
 
 // BibString is a segment of a bib string.
@@ -48,6 +46,27 @@ func (c BibConst) String() string {
 	return string(c)
 }
 
+// BibQuoted is a string constant enclosed in double quotes, like "this".
+type BibQuoted string
+
+// RawString is the internal representation of the constant, in quotes.
+func (c BibQuoted) RawString() string {
+	return fmt.Sprintf("\"%s\"", string(c))
+}
+
+func (c BibQuoted) String() string {
+	return string(c)
+}
+
+// literal returns a constant for a parsed string, remembering whether it was
+// quoted.
+func literal(s string, quoted bool) BibString {
+	if quoted {
+		return BibQuoted(s)
+	}
+	return NewBibConst(s)
+}
+
 // BibComposite is a composite string, may contain both variable and string.
 type BibComposite []BibString
 
@@ -59,8 +78,17 @@ func NewBibComposite(s BibString) *BibComposite {
 
 // Append adds a BibString to the composite
 func (c *BibComposite) Append(s BibString) *BibComposite {
-	comp := append(*c, s)
-	return &comp
+	*c = append(*c, s)
+	return c
+}
+
+// concat joins two strings with the # operator, extending s if it is already a
+// composite.
+func concat(s, t BibString) BibString {
+	if comp, ok := s.(*BibComposite); ok {
+		return comp.Append(t)
+	}
+	return NewBibComposite(s).Append(t)
 }
 
 func (c *BibComposite) String() string {
@@ -78,14 +106,7 @@ func (c *BibComposite) RawString() string {
 		if i > 0 {
 			buf.WriteString(" # ")
 		}
-		switch comp := comp.(type) {
-		case *BibConst:
-			buf.WriteString(comp.RawString())
-		case *BibVar:
-			buf.WriteString(comp.RawString())
-		case *BibComposite:
-			buf.WriteString(comp.RawString())
-		}
+		buf.WriteString(comp.RawString())
 	}
 	return buf.String()
 }
@@ -95,6 +116,23 @@ type BibEntry struct {
 	Type     string
 	CiteName string
 	Fields   map[string]BibString
+	Parens   bool // Entry is delimited by parentheses rather than braces.
+
+	// Alias is the type of the entry as parsed, if it was an alias such as
+	// @www normalised to the canonical Type.
+	Alias string
+
+	// RawType is the type exactly as written in the source, if parsed, such as
+	// Dataset for @Dataset. The Formatter writes it in place of Type when the
+	// two differ only in case, so unknown types keep their casing.
+	RawType string
+
+	// LeadingComments are the % comment lines directly above the entry, with
+	// no blank line between. They are written back out with the entry.
+	LeadingComments []string
+
+	start, end int      // Byte offsets in the source, if parsed.
+	order      []string // Field names in the order they were added.
 }
 
 // NewBibEntry creates a new BibTeX entry.
@@ -111,7 +149,73 @@ func NewBibEntry(entryType string, citeName string) *BibEntry {
 
 // AddField adds a field (key-value) to a BibTeX entry.
 func (entry *BibEntry) AddField(name string, value BibString) {
-	entry.Fields[strings.TrimSpace(name)] = value
+	name = strings.TrimSpace(name)
+	if _, ok := entry.Fields[name]; !ok {
+		entry.order = append(entry.order, name)
+	}
+	entry.Fields[name] = value
+}
+
+// Get returns the value of a field, ignoring the case of its name. An exact
+// match is preferred; otherwise the first match in FieldNamesInOrder is used.
+func (entry *BibEntry) Get(name string) (BibString, bool) {
+	if value, ok := entry.Fields[name]; ok {
+		return value, true
+	}
+	for _, key := range entry.FieldNamesInOrder() {
+		if strings.EqualFold(key, name) {
+			return entry.Fields[key], true
+		}
+	}
+	return nil, false
+}
+
+// deleteField removes a field, ignoring the case of its name.
+func (entry *BibEntry) deleteField(name string) {
+	for key := range entry.Fields {
+		if strings.EqualFold(key, name) {
+			delete(entry.Fields, key)
+		}
+	}
+}
+
+// FieldNamesInOrder returns the names of the fields of the entry in the order
+// they were added, such as their order in the source. Fields set directly in
+// the Fields map follow, sorted by name. The returned slice is a copy.
+func (entry *BibEntry) FieldNamesInOrder() []string {
+	names := make([]string, 0, len(entry.Fields))
+	seen := map[string]bool{}
+	for _, name := range entry.order {
+		if _, ok := entry.Fields[name]; ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range entry.Fields {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// SourceRange returns the start and end byte offsets of the entry in the
+// source it was parsed from, such that source[start:end] is the entry text.
+// Both are zero for entries that were not produced by the parser.
+func (entry *BibEntry) SourceRange() (start, end int) {
+	return entry.start, entry.end
+}
+
+// fieldString returns the trimmed value of a field, or empty if it is not
+// present. The field name is matched ignoring case.
+func fieldString(entry *BibEntry, field string) string {
+	value, ok := entry.Get(field)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(value.String())
 }
 
 // BibTex is a list of BibTeX entries.
@@ -119,6 +223,9 @@ type BibTex struct {
 	Preambles []BibString        // List of Preambles
 	Entries   []*BibEntry        // Items in a bibliography.
 	StringVar map[string]*BibVar // Map from string variable to string.
+	Warnings  []error            // Problems found while parsing that were not fatal.
+
+	source []byte // Parsed source, if any.
 }
 
 // NewBibTex creates a new BibTex data structure.
@@ -130,6 +237,41 @@ func NewBibTex() *BibTex {
 	}
 }
 
+// Source returns the source text the BibTex was parsed from. It is nil for a
+// BibTex that was not produced by the parser.
+func (bib *BibTex) Source() []byte {
+	return bib.source
+}
+
+// WalkSource calls fn for each entry in the order it appears in the source,
+// with raw holding the text of the entry as parsed, so that unchanged entries
+// can be copied through verbatim. Entries that were not parsed from the
+// source follow in their order in Entries, with a nil raw. The raw text is
+// shared with Source and must not be modified. Walking stops at the first
+// error returned by fn, which is returned.
+func (bib *BibTex) WalkSource(fn func(entry *BibEntry, raw []byte) error) error {
+	var parsed, added []*BibEntry
+	for _, entry := range bib.Entries {
+		if entry.end > entry.start && entry.end <= len(bib.source) {
+			parsed = append(parsed, entry)
+		} else {
+			added = append(added, entry)
+		}
+	}
+	sort.SliceStable(parsed, func(i, j int) bool { return parsed[i].start < parsed[j].start })
+	for _, entry := range parsed {
+		if err := fn(entry, bib.source[entry.start:entry.end:entry.end]); err != nil {
+			return err
+		}
+	}
+	for _, entry := range added {
+		if err := fn(entry, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddPreamble adds a preamble to a bibtex.
 func (bib *BibTex) AddPreamble(p BibString) {
 	bib.Preambles = append(bib.Preambles, p)
@@ -140,20 +282,41 @@ func (bib *BibTex) AddEntry(entry *BibEntry) {
 	bib.Entries = append(bib.Entries, entry)
 }
 
-// AddStringVar adds a new string var (if does not exist).
+// AddStringVar adds a new string var (if does not exist). String variable names
+// are case-insensitive, and are stored in lowercase.
 func (bib *BibTex) AddStringVar(key string, val BibString) {
-	bib.StringVar[key] = &BibVar{Key: key, Value: val}
+	bib.StringVar[strings.ToLower(key)] = &BibVar{Key: key, Value: val}
 }
 
-// GetStringVar looks up a string by its key.
+// GetStringVar looks up a string by its key, ignoring case. The month macros
+// jan to dec are predefined. Returns nil if the string variable is undefined.
 func (bib *BibTex) GetStringVar(key string) *BibVar {
-	if bv, ok := bib.StringVar[key]; ok {
+	if bv, ok := bib.StringVar[strings.ToLower(key)]; ok {
+		return bv
+	}
+	if bv, ok := monthVar(key); ok {
 		return bv
 	}
-	log.Fatalf("%s: %s", ErrUnknownStringVar, key)
 	return nil
 }
 
+// FieldNames returns the distinct field names used across all entries, in
+// lowercase and sorted.
+func (bib *BibTex) FieldNames() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, entry := range bib.Entries {
+		for key := range entry.Fields {
+			if name := strings.ToLower(key); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // String returns a BibTex data structure as a simplified BibTex string.
 func (bib *BibTex) String() string {
 	var bibtex bytes.Buffer
@@ -175,8 +338,8 @@ func (bib *BibTex) String() string {
 // RawString returns a BibTex data structure in its internal representation.
 func (bib *BibTex) RawString() string {
 	var bibtex bytes.Buffer
-	for k, strvar := range bib.StringVar {
-		bibtex.WriteString(fmt.Sprintf("@string{%s = {%s}}\n", k, strvar.String()))
+	for _, k := range bib.StringVarOrder() {
+		bibtex.WriteString(fmt.Sprintf("@string{%s = {%s}}\n", k, bib.StringVar[k].String()))
 	}
 	for _, preamble := range bib.Preambles {
 		bibtex.WriteString(fmt.Sprintf("@preamble{%s}\n", preamble.RawString()))
@@ -199,38 +362,8 @@ func (bib *BibTex) RawString() string {
 // PrettyString pretty prints a BibTex.
 func (bib *BibTex) PrettyString() string {
 	var buf bytes.Buffer
-	for i, entry := range bib.Entries {
-		if i != 0 {
-			fmt.Fprint(&buf, "\n")
-		}
-		fmt.Fprintf(&buf, "@%s{%s,\n", entry.Type, entry.CiteName)
-
-		// Determine key order.
-		keys := []string{}
-		for key := range entry.Fields {
-			keys = append(keys, key)
-		}
-
-		priority := map[string]int{"title": -3, "author": -2, "url": -1}
-		sort.Slice(keys, func(i, j int) bool {
-			pi, pj := priority[keys[i]], priority[keys[j]]
-			return pi < pj || (pi == pj && keys[i] < keys[j])
-		})
-
-		// Write fields.
-		tw := tabwriter.NewWriter(&buf, 1, 4, 1, ' ', 0)
-		for _, key := range keys {
-			value := entry.Fields[key].String()
-			format := stringformat(value)
-			fmt.Fprintf(tw, "    %s\t=\t"+format+",\n", key, value)
-		}
-		tw.Flush()
-
-		// Close.
-		buf.WriteString("}\n")
-	}
+	_ = (&Formatter{}).Format(&buf, bib)
 	return buf.String()
-
 }
 
 // stringformat determines the correct formatting verb for the given BibTeX field value.
@@ -245,6 +378,7 @@ func stringformat(v string) string {
 		return "{%s}"
 	}
 
-	// Default to quoted string.
-	return "%q"
+	// Default to quoted string. Quotes are written directly, since escaping
+	// backslashes as Go does would change LaTeX commands.
+	return "\"%s\""
 }
@@ -2,20 +2,25 @@
 package bibtex
 
 import (
+	"bytes"
 	"io"
+	"io/ioutil"
+	"strings"
 )
 
 type bibTag struct {
 	key string
 	val BibString
+	pos TokenPos // Position of the key.
 }
-
-var bib *BibTex // Only for holding current bib
 %}
 
 %union {
 	bibtex   *BibTex
 	strval   string
+	offset   int
+	quoted   bool
+	pos      TokenPos
 	bibentry *BibEntry
 	bibtag   *bibTag
 	bibtags  []*bibTag
@@ -33,56 +38,151 @@ var bib *BibTex // Only for holding current bib
 
 %%
 
-top : bibtex { }
+top : bibtex { bibtexlex.(*Lexer).resolveForward() }
     ;
 
-bibtex : /* empty */          { $$ = NewBibTex(); bib = $$ }
-       | bibtex bibentry      { $$ = $1; $$.AddEntry($2) }
+bibtex : /* empty */          { $$ = NewBibTex(); bibtexlex.(*Lexer).bib = $$ }
+       | bibtex bibentry      { $$ = $1; bibtexlex.(*Lexer).addEntry($2) }
        | bibtex commententry  { $$ = $1 }
-       | bibtex stringentry   { $$ = $1; $$.AddStringVar($2.key, $2.val) }
+       | bibtex stringentry   { $$ = $1; bibtexlex.(*Lexer).defineStringVar($2.key, $2.val) }
        | bibtex preambleentry { $$ = $1; $$.AddPreamble($2) }
        ;
 
-bibentry : ATSIGN BAREIDENT LBRACE BAREIDENT COMMA tags RBRACE { $$ = NewBibEntry($2, $4); for _, t := range $6 { $$.AddField(t.key, t.val) } }
-         | ATSIGN BAREIDENT LPAREN BAREIDENT COMMA tags RPAREN { $$ = NewBibEntry($2, $4); for _, t := range $6 { $$.AddField(t.key, t.val) } }
+bibentry : ATSIGN BAREIDENT LBRACE BAREIDENT COMMA tags RBRACE { $$ = bibtexlex.(*Lexer).entry($<offset>1, $<offset>7+1, $2, $4, $<pos>4, $6) }
+         | ATSIGN BAREIDENT LPAREN BAREIDENT COMMA tags RPAREN { $$ = bibtexlex.(*Lexer).entry($<offset>1, $<offset>7+1, $2, $4, $<pos>4, $6); $$.Parens = true }
          ;
 
 commententry : ATSIGN COMMENT LBRACE longstring RBRACE {}
-             | ATSIGN COMMENT LPAREN longstring RBRACE {}
+             | ATSIGN COMMENT LPAREN longstring RPAREN {}
              ;
 
 stringentry : ATSIGN STRING LBRACE BAREIDENT EQUAL longstring RBRACE { $$ = &bibTag{key: $4, val: $6 } }
-            | ATSIGN STRING LPAREN BAREIDENT EQUAL longstring RBRACE { $$ = &bibTag{key: $4, val: $6 } }
+            | ATSIGN STRING LPAREN BAREIDENT EQUAL longstring RPAREN { $$ = &bibTag{key: $4, val: $6 } }
             ;
 
 preambleentry : ATSIGN PREAMBLE LBRACE longstring RBRACE { $$ = $4 }
               | ATSIGN PREAMBLE LPAREN longstring RPAREN { $$ = $4 }
               ;
 
-longstring :                  IDENT     { $$ = NewBibConst($1) }
-           |                  BAREIDENT { $$ = bib.GetStringVar($1) }
-           | longstring POUND IDENT     { $$ = NewBibComposite($1); $$.(*BibComposite).Append(NewBibConst($3))}
-           | longstring POUND BAREIDENT { $$ = NewBibComposite($1); $$.(*BibComposite).Append(bib.GetStringVar($3)) }
+longstring :                  IDENT     { $$ = literal($1, $<quoted>1) }
+           |                  BAREIDENT { $$ = bibtexlex.(*Lexer).stringVar($1) }
+           | longstring POUND IDENT     { $$ = concat($1, literal($3, $<quoted>3)) }
+           | longstring POUND BAREIDENT { $$ = concat($1, bibtexlex.(*Lexer).stringVar($3)) }
            ;
 
-tag : /* empty */                { }
-    | BAREIDENT EQUAL longstring { $$ = &bibTag{key: $1, val: $3} }
+tag : /* empty */                { $$ = nil }
+    | BAREIDENT EQUAL longstring { $$ = &bibTag{key: $1, val: $3, pos: $<pos>1} }
     ;
 
-tags : tag            { $$ = []*bibTag{$1} }
+tags : tag            { if $1 == nil { $$ = nil } else { $$ = []*bibTag{$1} } }
      | tags COMMA tag { if $3 == nil { $$ = $1 } else { $$ = append($1, $3) } }
      ;
 
 %%
 
+// Parser parses bibtex with configurable options. The zero value is ready to
+// use.
+type Parser struct {
+	Logger Logger // Destination for diagnostic messages, discarded if nil.
+
+	// FieldHook, if set, is called with each field as it is parsed. The
+	// returned value is stored in place of the parsed value.
+	FieldHook func(entryType, key, field, value string) string
+
+	// Types, if non-empty, restricts parsing to entries of the listed types.
+	// Other entries are skipped by the scanner without being parsed.
+	Types []string
+
+	// NormalizePunctuation applies NormalizePunctuation to field values as
+	// they are parsed, after FieldHook.
+	NormalizePunctuation bool
+
+	// StrictStrings makes redefining a string variable a parse error. By
+	// default the later definition wins and a warning is recorded.
+	StrictStrings bool
+
+	// StrictMacroOrder makes using a string variable before its definition a
+	// parse error, as in BibTeX. By default such forward references resolve
+	// to the definition once the whole input is read, but FieldHook and
+	// NormalizePunctuation see them as empty.
+	StrictMacroOrder bool
+
+	// MaxValueLength, if positive, makes a braced or quoted value longer than
+	// this many runes a parse error. See Scanner.MaxValueLength.
+	MaxValueLength int
+
+	// StrictKeys makes a citation key given to more than one entry a parse
+	// error. Keys are compared ignoring case, as BibTeX does. By default both
+	// entries are kept and a warning is recorded.
+	StrictKeys bool
+
+	// StrictFields makes a field given twice in one entry a parse error. By
+	// default the later value wins and a warning is recorded.
+	StrictFields bool
+}
+
+// wantType reports whether entries of the given type should be parsed. Type
+// aliases match their canonical type.
+func (p *Parser) wantType(entryType string) bool {
+	return containsFold(p.Types, entryType) || containsFold(p.Types, canonicalType(strings.ToLower(entryType)))
+}
+
 // Parse is the entry point to the bibtex parser.
 func Parse(r io.Reader) (*BibTex, error) {
-	l := NewLexer(r)
+	return (&Parser{}).Parse(r)
+}
+
+// utf8BOM is the byte order mark some editors write at the start of UTF-8
+// files. It is skipped by the parser.
+var utf8BOM = []byte("\ufeff")
+
+// ParseBytes parses a bibtex from data.
+func ParseBytes(data []byte) (*BibTex, error) {
+	return (&Parser{}).ParseBytes(data)
+}
+
+// Parse parses a bibtex from r, reading it to the end first.
+func (p *Parser) Parse(r io.Reader) (*BibTex, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseBytes(data)
+}
+
+// ParseBytes parses a bibtex from data. The result refers to data for the
+// source text of entries, so data must not be modified afterwards.
+func (p *Parser) ParseBytes(data []byte) (*BibTex, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	size := len(data)
+	if size > maxBufferSize {
+		size = maxBufferSize
+	}
+	l := newLexer(NewScannerSize(bytes.NewReader(data), size))
+	l.parser = p
+	l.scanner.Logger = p.Logger
+	l.scanner.MaxValueLength = p.MaxValueLength
 	bibtexParse(l)
 	select {
 	case err := <-l.Errors:
 		return nil, err
 	default:
-		return bib, nil
+		l.bib.source = data
+		return l.bib, nil
+	}
+}
+
+// Valid checks that r is syntactically valid bibtex, returning the first error
+// found. It is faster than Parse, as entries are not built and string
+// variables are not resolved.
+func Valid(r io.Reader) error {
+	l := NewLexer(r)
+	l.validate = true
+	bibtexParse(l)
+	select {
+	case err := <-l.Errors:
+		return err
+	default:
+		return nil
 	}
 }
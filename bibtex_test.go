@@ -2,8 +2,11 @@ package bibtex
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"log"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -44,6 +47,47 @@ func TestSpaces(t *testing.T) {
 	}
 }
 
+// Test that whitespace between the entry type and delimiter is allowed, as
+// written by some exporters.
+func TestSpaceBeforeDelimiter(t *testing.T) {
+	expect := MustParse(t, `@article{smith, title={X}}`).Entries
+	for _, src := range []string{
+		`@article {smith, title={X}}`,
+		"@article\n\t{smith, title={X}}",
+		`@ article {smith, title={X}}`,
+	} {
+		AssertEntryListsEqual(t, MustParse(t, src).Entries, expect)
+	}
+
+	bib, err := (&Parser{Types: []string{"article"}}).Parse(strings.NewReader(`@book {skipped, title={Y}} @article {smith, title={X}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEntryListsEqual(t, bib.Entries, expect)
+}
+
+// Test that an @ in a braced value, as in an email address, is literal.
+func TestAtsignInValue(t *testing.T) {
+	bib := MustParse(t, `@misc{a, author = {John Doe <john@example.com>}, note = {@handle}}
+@misc{b, title = {B}}`)
+	if len(bib.Entries) != 2 {
+		t.Fatalf("got %d entries, expected 2", len(bib.Entries))
+	}
+	AssertFields(t, bib.Entries[0], map[string]string{"author": "John Doe <john@example.com>", "note": "@handle"})
+}
+
+// Test that # in a braced or quoted value is literal, and only concatenates
+// between values.
+func TestPoundInValue(t *testing.T) {
+	bib := MustParse(t, `@string{lang = {F#}}
+@misc{a, title = {C# programming}, note = "Issue #4", howpublished = lang # { and } # "C#"}`)
+	AssertFields(t, bib.Entries[0], map[string]string{
+		"title":        "C# programming",
+		"note":         "Issue #4",
+		"howpublished": "F# and C#",
+	})
+}
+
 func TestString(t *testing.T) {
 	bibtex := NewBibTex()
 	bibtex.AddStringVar("cat", &BibVar{Key: "cat", Value: NewBibConst("meowmeow")})
@@ -81,6 +125,33 @@ func TestParser(t *testing.T) {
 	}
 }
 
+// Test that ParseBytes agrees with Parse on all files in the example/ dir.
+func TestParseBytes(t *testing.T) {
+	examples, err := filepath.Glob("example/*.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ex := range examples {
+		b, err := ioutil.ReadFile(ex)
+		if err != nil {
+			t.Fatalf("Cannot read %s: %v", ex, err)
+		}
+		expect, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("Cannot parse %s: %v", ex, err)
+		}
+		got, err := ParseBytes(b)
+		if err != nil {
+			t.Fatalf("Cannot parse %s from bytes: %v", ex, err)
+		}
+		AssertEntryListsEqual(t, got.Entries, expect.Entries)
+		if !bytes.Equal(got.Source(), b) {
+			t.Errorf("%s: ParseBytes source differs from input", ex)
+		}
+	}
+}
+
 // Tests that multiple parse returns different instances of the parsed BibTex.
 // Otherwise the number of entries will pile up. (Issue #4)
 func TestMultiParse(t *testing.T) {
@@ -127,7 +198,7 @@ func TestPrettyStringRoundTrip(t *testing.T) {
 		}
 
 		// Parse into BibTeX.
-		bib, err = Parse(bytes.NewReader(b))
+		bib, err := Parse(bytes.NewReader(b))
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -140,11 +211,30 @@ func TestPrettyStringRoundTrip(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		// Check equality.
-		AssertEntryListsEqual(t, bib.Entries, bib2.Entries)
+		// Check equality. The formatter sorts fields, so the structure is
+		// compared after a second pass.
+		if len(bib.Entries) != len(bib2.Entries) {
+			t.Fatalf("length mismatch")
+		}
+		for i := range bib.Entries {
+			if !EntriesEqual(bib.Entries[i], bib2.Entries[i]) {
+				t.Errorf("entry %s: entries not equal", bib.Entries[i].CiteName)
+			}
+		}
+		AssertEntryListsEqual(t, bib2.Entries, MustParse(t, bib2.PrettyString()).Entries)
 	}
 }
 
+// MustParse parses src, failing the test on error.
+func MustParse(t *testing.T, src string) *BibTex {
+	t.Helper()
+	bib, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bib
+}
+
 func AssertEntryListsEqual(t *testing.T, a, b []*BibEntry) {
 	t.Helper()
 
@@ -158,6 +248,12 @@ func AssertEntryListsEqual(t *testing.T, a, b []*BibEntry) {
 }
 
 func AssertEntriesEqual(t *testing.T, a, b *BibEntry) {
+	if !EntriesEqual(a, b) {
+		t.Error("entries not equal")
+	}
+	if !a.Equal(b) {
+		t.Error("entries not structurally equal")
+	}
 	if a.Type != b.Type {
 		t.Error("type mismatch")
 	}
@@ -173,3 +269,389 @@ func AssertEntriesEqual(t *testing.T, a, b *BibEntry) {
 		}
 	}
 }
+
+// Tests that WalkSource visits entries in source order with their raw text,
+// followed by entries that were not parsed.
+func TestWalkSource(t *testing.T) {
+	entries := []string{
+		"@article{a,\n  title = {A}\n}",
+		"@misc(b, note = \"Caf\\'e\")",
+		"@book{c, title = {C}}",
+	}
+	src := "% header\n" + strings.Join(entries, "\n% between\n")
+	bib := MustParse(t, src)
+	bib.Entries[0], bib.Entries[2] = bib.Entries[2], bib.Entries[0]
+	bib.AddEntry(NewBibEntry("misc", "added"))
+
+	var keys []string
+	err := bib.WalkSource(func(entry *BibEntry, raw []byte) error {
+		if i := len(keys); i < len(entries) && string(raw) != entries[i] {
+			t.Errorf("%s: got raw %q, expected %q", entry.CiteName, raw, entries[i])
+		}
+		if entry.CiteName == "added" && raw != nil {
+			t.Errorf("added entry has raw %q", raw)
+		}
+		keys = append(keys, entry.CiteName)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(keys, ","); got != "a,b,c,added" {
+		t.Errorf("walked %s", got)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	if err := bib.WalkSource(func(*BibEntry, []byte) error { n++; return stop }); err != stop || n != 1 {
+		t.Errorf("got %v after %d calls, expected to stop after one", err, n)
+	}
+}
+
+// Tests that entry source ranges slice out exactly the text of each entry.
+func TestSourceRange(t *testing.T) {
+	entries := []string{
+		"@article{name,\n  year = 2016,\n  title = {Session {B}ased}\n}",
+		"@inproceedings{ng2014,\n  title = \"Blah\",\n  author = \"Me\"\n}",
+		"@misc{m, note = {Café}}",
+	}
+	src := "@string{x = {X}}\n\n" + strings.Join(entries, "\n\n") + "\n"
+
+	bib, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bib.Source()) != src {
+		t.Fatalf("source mismatch")
+	}
+	if want, got := len(entries), len(bib.Entries); want != got {
+		t.Fatalf("Expecting %d entries but got %d", want, got)
+	}
+	for i, entry := range bib.Entries {
+		start, end := entry.SourceRange()
+		if got := string(bib.Source()[start:end]); got != entries[i] {
+			t.Errorf("entry %d: got source %q, expected %q", i, got, entries[i])
+		}
+	}
+}
+
+// Tests that diagnostics go to the configured logger, and nowhere by default.
+func TestLogger(t *testing.T) {
+	src := "@article{a, title = undefined}"
+
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+
+	if _, err := Parse(strings.NewReader(src)); err == nil {
+		t.Fatal("expected error for undefined string variable")
+	}
+	if std.Len() != 0 {
+		t.Errorf("default logger produced output: %q", std.String())
+	}
+
+	var buf bytes.Buffer
+	p := &Parser{Logger: log.New(&buf, "", 0)}
+	if _, err := p.Parse(strings.NewReader(src)); err == nil {
+		t.Fatal("expected error for undefined string variable")
+	}
+	if expect := "Unknown string variable: undefined\n"; buf.String() != expect {
+		t.Errorf("got log %q, expected %q", buf.String(), expect)
+	}
+}
+
+func TestFieldHook(t *testing.T) {
+	p := &Parser{
+		FieldHook: func(entryType, key, field, value string) string {
+			if field == "title" {
+				return strings.ToUpper(value)
+			}
+			return value
+		},
+	}
+	bib, err := p.Parse(strings.NewReader(`@article{a, title = {Hello World}, author = {Me}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := bib.Entries[0]
+	if got := entry.Fields["title"].String(); got != "HELLO WORLD" {
+		t.Errorf("hook not applied to title: %q", got)
+	}
+	if got := entry.Fields["author"].String(); got != "Me" {
+		t.Errorf("hook changed author: %q", got)
+	}
+}
+
+// Tests that @string entries parse into the expected name and value.
+func TestStringEntry(t *testing.T) {
+	cases := []struct {
+		Src      string
+		Name     string
+		Value    string
+		RawValue string
+	}{
+		{`@string{ieee = {IEEE}}`, "ieee", "IEEE", "{IEEE}"},
+		{`@string{ieee = "IEEE {Trans.}"}`, "ieee", "IEEE Trans.", `"IEEE Trans."`},
+		{`@string{pre = {IEEE}} @string{Ieee = pre # " Trans. on " # {Software}}`, "ieee", "IEEE Trans. on Software", `pre # " Trans. on " # {Software}`},
+	}
+	for _, c := range cases {
+		bib := MustParse(t, c.Src)
+		v := bib.GetStringVar(c.Name)
+		if v == nil {
+			t.Errorf("%s: string variable %q not defined", c.Src, c.Name)
+			continue
+		}
+		if got := v.String(); got != c.Value {
+			t.Errorf("%s: got value %q, expected %q", c.Src, got, c.Value)
+		}
+		if got := v.Value.RawString(); got != c.RawValue {
+			t.Errorf("%s: got raw value %q, expected %q", c.Src, got, c.RawValue)
+		}
+	}
+}
+
+// Tests that string variables are case-insensitive and are not confused with
+// field names of the same name.
+func TestStringEntryNames(t *testing.T) {
+	bib := MustParse(t, `@string{title = {Macro}} @article{a, title = TITLE # { Title}}`)
+	if got := bib.Entries[0].Fields["title"].String(); got != "Macro Title" {
+		t.Errorf("got title %q", got)
+	}
+}
+
+// Tests that the later definition of a string variable wins, with a warning,
+// and that StrictStrings rejects the redefinition.
+func TestStringRedefinition(t *testing.T) {
+	src := `@string{ieee = {IEEE}} @string{IEEE = {Institute}} @misc{a, publisher = ieee}`
+	bib := MustParse(t, src)
+	if got := bib.Entries[0].Fields["publisher"].String(); got != "Institute" {
+		t.Errorf("got publisher %q, expected the later definition", got)
+	}
+	if len(bib.Warnings) != 1 || !errors.Is(bib.Warnings[0], ErrRedefinedStringVar) {
+		t.Errorf("got warnings %v, expected one redefinition", bib.Warnings)
+	}
+
+	p := &Parser{StrictStrings: true}
+	if _, err := p.Parse(strings.NewReader(src)); !errors.Is(err, ErrRedefinedStringVar) {
+		t.Errorf("got error %v, expected ErrRedefinedStringVar", err)
+	}
+}
+
+// Tests that a string variable used before its definition resolves by
+// default, and that StrictMacroOrder rejects the forward reference.
+func TestStringForwardReference(t *testing.T) {
+	src := `@misc{a, publisher = ieee # " Press", journal = ieee} @string{ieee = {IEEE}}`
+	bib := MustParse(t, src)
+	entry := bib.Entries[0]
+	if got := entry.Fields["publisher"].String(); got != "IEEE Press" {
+		t.Errorf("got publisher %q", got)
+	}
+	if got := entry.Fields["journal"].RawString(); got != "ieee" {
+		t.Errorf("got journal %q, expected the macro to be kept", got)
+	}
+
+	p := &Parser{StrictMacroOrder: true}
+	if _, err := p.Parse(strings.NewReader(src)); !errors.Is(err, ErrUnknownStringVar) {
+		t.Errorf("got error %v, expected ErrUnknownStringVar", err)
+	}
+	if _, err := Parse(strings.NewReader(`@misc{a, journal = jacm} @string{acm = {ACM}}`)); !errors.Is(err, ErrUnknownStringVar) {
+		t.Errorf("got error %v for a variable never defined", err)
+	}
+}
+
+// Tests that the later value of a repeated field wins, with a warning giving
+// both values, and that StrictFields rejects the repetition.
+func TestDuplicateField(t *testing.T) {
+	src := "@misc{a,\n  year = 2020,\n  title = {T},\n  Year = 2021,\n}"
+	bib := MustParse(t, src)
+	entry := bib.Entries[0]
+	if v, ok := entry.Get("year"); !ok || v.String() != "2021" || len(entry.Fields) != 2 {
+		t.Errorf("got fields %v, expected the later year", entry.Fields)
+	}
+	if len(bib.Warnings) != 1 || !errors.Is(bib.Warnings[0], ErrDuplicateField) {
+		t.Fatalf("got warnings %v, expected one duplicate field", bib.Warnings)
+	}
+	expect := `Parse failed at 4:3: Duplicate field: Year = "2021", previously "2020" at 2:3`
+	if got := bib.Warnings[0].Error(); got != expect {
+		t.Errorf("got warning %q, expected %q", got, expect)
+	}
+
+	p := &Parser{StrictFields: true}
+	if _, err := p.Parse(strings.NewReader(src)); !errors.Is(err, ErrDuplicateField) {
+		t.Errorf("got error %v, expected ErrDuplicateField", err)
+	}
+}
+
+// Tests that entries of unwanted types are skipped, including bodies with
+// nested braces that would not otherwise parse.
+func TestParserTypes(t *testing.T) {
+	src := `
+@string{j = {Journal}}
+@book{skipped, title = {Nested {braces {deep}} and @ sign}, note = "{x}"}
+@article{a, title = {A}, journal = j}
+@misc(alsoskipped, title = {paren (style)})
+@misc(closeparen, title = {Results :)}, note = "a) first")
+@article(c, title = {C})
+@Article{b, title = {B}}
+`
+	p := &Parser{Types: []string{"article"}}
+	bib, err := p.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, entry := range bib.Entries {
+		keys = append(keys, entry.CiteName)
+	}
+	if got := strings.Join(keys, ","); got != "a,c,b" {
+		t.Errorf("got entries %s, expected a,c,b", got)
+	}
+	if got := bib.Entries[0].Fields["journal"].String(); got != "Journal" {
+		t.Errorf("string variable not resolved: %q", got)
+	}
+
+	if _, err := p.Parse(strings.NewReader(`@book{unterminated, title = {T}`)); err == nil {
+		t.Errorf("expected error for unterminated skipped entry")
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	bib := MustParse(t, `
+@article{a, title = {A}, Author = {X}, mendeley-tags = {t}}
+@book{b, TITLE = {B}, bdsk-url-1 = {http://example.com}, year = 2020}
+`)
+	expect := "author,bdsk-url-1,mendeley-tags,title,year"
+	if got := strings.Join(bib.FieldNames(), ","); got != expect {
+		t.Errorf("got %s, expected %s", got, expect)
+	}
+}
+
+func TestFieldNamesInOrder(t *testing.T) {
+	entry := MustParse(t, `@article{a, year = 2020, Title = {A}, author = {X}, journal = {J}}`).Entries[0]
+	entry.Fields["abstract"] = NewBibConst("set directly")
+	delete(entry.Fields, "author")
+
+	names := entry.FieldNamesInOrder()
+	expect := "year,Title,journal,abstract"
+	if got := strings.Join(names, ","); got != expect {
+		t.Fatalf("got %s, expected %s", got, expect)
+	}
+	names[0] = "mutated"
+	if got := strings.Join(entry.FieldNamesInOrder(), ","); got != expect {
+		t.Errorf("mutating the returned slice changed the entry: %s", got)
+	}
+}
+
+func TestEntryGet(t *testing.T) {
+	entry := MustParse(t, `@article{a, Title = {A}}`).Entries[0]
+	for _, name := range []string{"Title", "title", "TITLE"} {
+		if v, ok := entry.Get(name); !ok || v.String() != "A" {
+			t.Errorf("Get(%q) = %v, %v", name, v, ok)
+		}
+	}
+	if _, ok := entry.Get("author"); ok {
+		t.Errorf("Get of missing field succeeded")
+	}
+}
+
+func TestEntryGetCaseVariants(t *testing.T) {
+	entry := NewBibEntry("misc", "a")
+	entry.Fields["Year"] = NewBibConst("2021")
+	entry.Fields["YEAR"] = NewBibConst("2020")
+	for i := 0; i < 100; i++ {
+		if v, ok := entry.Get("year"); !ok || v.String() != "2020" {
+			t.Fatalf("Get(%q) = %v, %v", "year", v, ok)
+		}
+	}
+
+	entry.order = []string{"Year", "YEAR"}
+	for i := 0; i < 100; i++ {
+		if v, ok := entry.Get("year"); !ok || v.String() != "2021" {
+			t.Fatalf("Get(%q) = %v, %v", "year", v, ok)
+		}
+	}
+}
+
+// Tests that tool-specific fields survive formatting and parsing untouched.
+func TestToolFieldsRoundTrip(t *testing.T) {
+	fields := map[string]string{
+		"bdsk-file-1":   "YnBsaXN0MDDUAQIDBAUGJCVYJHZlcnNpb25YJG9iamVjdHNZJGFyY2hpdmVyVCR0b3ASAAGGoKgHCBMUFRYaIVUkbnVsbNMJCgsMDxJXTlMua2V5c1pOUy5vYmplY3RzViRjbGFzc6INDoACgAOiEBGABIAFgAdccmVsYXRpdmVQYXRoWWFsaWFzRGF0YV8QHy4uLy4uL1BhcGVycy9Eb2UyMDIwLnBkZk8RAVQ=",
+		"__markedentry": "[user:6]",
+		"mendeley-tags": "tag1,tag2",
+		"bdsk-url-1":    "http://example.com/a%20b?q=1&r=2",
+	}
+	var src strings.Builder
+	src.WriteString("@article{a,\n")
+	for key, value := range fields {
+		fmt.Fprintf(&src, "  %s = {%s},\n", key, value)
+	}
+	src.WriteString("}\n")
+
+	bib := MustParse(t, src.String())
+	again := MustParse(t, bib.PrettyString())
+	for _, b := range []*BibTex{bib, again} {
+		for key, value := range b.Entries[0].Fields {
+			if got := value.String(); got != fields[key] {
+				t.Errorf("field %s: got %q, expected %q", key, got, fields[key])
+			}
+		}
+		if len(b.Entries[0].Fields) != len(fields) {
+			t.Errorf("got fields %v", b.Entries[0].Fields)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	f, err := os.Open("example/biblatex-examples.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := Valid(f); err != nil {
+		t.Errorf("unexpected error for valid file: %v", err)
+	}
+
+	// Undefined string variables are not resolved, so are not an error.
+	if err := Valid(strings.NewReader(`@article{a, journal = jacm}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = Valid(strings.NewReader("@article{a,\n  title = {T},\n  year = = 2020}"))
+	expect := "Parse failed at 3:10: syntax error"
+	if err == nil || err.Error() != expect {
+		t.Errorf("got error %v, expected %q", err, expect)
+	}
+}
+
+// Tests that a value missing its closing brace is reported at its start when
+// it exceeds MaxValueLength, and that values within the limit parse.
+func TestMaxValueLength(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("@misc{a, title = {Fine}}\n@misc{b,\n  title = {Missing close,\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&src, "@misc{e%d, title = {Entry %d}}\n", i, i)
+	}
+
+	p := &Parser{MaxValueLength: 100}
+	_, err := p.Parse(strings.NewReader(src.String()))
+	var perr *ErrParse
+	if !errors.As(err, &perr) || perr.Kind != ErrValueTooLong {
+		t.Fatalf("got error %v, expected ErrValueTooLong", err)
+	}
+	if got := perr.Pos.String(); got != "3:11" {
+		t.Errorf("got position %s, expected the start of the value", got)
+	}
+	if _, err := Parse(strings.NewReader(src.String())); errors.Is(err, ErrValueTooLong) {
+		t.Errorf("got %v without a limit", err)
+	}
+
+	p = &Parser{MaxValueLength: 4}
+	if _, err := p.Parse(strings.NewReader(`@misc{a, title = {Fine}, note = "Four", x = {\{\}}}`)); err != nil {
+		t.Errorf("values at the limit rejected: %v", err)
+	}
+	for _, value := range []string{`{\{\{\{}`, `"\"\"\""`} {
+		if _, err := p.Parse(strings.NewReader(`@misc{a, title = ` + value + `}`)); !errors.Is(err, ErrValueTooLong) {
+			t.Errorf("%s: got error %v, expected escapes to count as two characters", value, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package bibtex
+
+// EntryBuilder constructs an entry field by field, as in
+//
+//	entry, err := NewEntry("article", "smith2020").
+//		Set("author", "Smith, J.").
+//		Set("title", "X").
+//		Build()
+type EntryBuilder struct {
+	entryType string
+	entry     *BibEntry
+	validator *Validator
+}
+
+// NewEntry returns a builder for an entry of the given type and citation key.
+func NewEntry(entryType, key string) *EntryBuilder {
+	return &EntryBuilder{entryType: entryType, entry: &BibEntry{CiteName: key, Fields: map[string]BibString{}}}
+}
+
+// Set sets a field to a constant value. Fields are kept in the order they are
+// first set.
+func (b *EntryBuilder) Set(name, value string) *EntryBuilder {
+	return b.SetValue(name, NewBibConst(value))
+}
+
+// SetValue sets a field to a value, which may use string variables.
+func (b *EntryBuilder) SetValue(name string, value BibString) *EntryBuilder {
+	b.entry.AddField(name, value)
+	return b
+}
+
+// Validate makes Build check the entry with v, such as a Validator with
+// RequiredFields set.
+func (b *EntryBuilder) Validate(v *Validator) *EntryBuilder {
+	b.validator = v
+	return b
+}
+
+// Build returns the entry. The type is normalized as by BibEntry.SetType, and
+// an invalid type or citation key is an error. If a validator was given, any
+// issues of error severity it finds are returned as ValidationErrors, along
+// with the entry. Each call returns a new entry.
+func (b *EntryBuilder) Build() (*BibEntry, error) {
+	entry := &BibEntry{CiteName: b.entry.CiteName, Fields: map[string]BibString{}}
+	for _, name := range b.entry.order {
+		entry.AddField(name, b.entry.Fields[name])
+	}
+	if err := entry.SetType(b.entryType, nil); err != nil {
+		return nil, err
+	}
+	var errs ValidationErrors
+	issues := checkKey(nil, entry)
+	if b.validator != nil {
+		issues = b.validator.ValidateEntry(entry) // Includes the key check.
+	}
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) > 0 {
+		return entry, errs
+	}
+	return entry, nil
+}
@@ -0,0 +1,51 @@
+package bibtex
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEntryBuilder(t *testing.T) {
+	entry, err := NewEntry("Article", "smith2020").
+		Set("author", "Smith, J.").
+		Set("title", "X").
+		SetValue("month", &BibVar{Key: "jul", Value: NewBibConst("July")}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Type != "article" || len(entry.FieldNamesInOrder()) != 3 || entry.FieldNamesInOrder()[0] != "author" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	var buf bytes.Buffer
+	if err := WriteEntry(&buf, entry, Formatter{PreserveMacros: true}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "@article{smith2020,\n    title  = \"X\",\n    author = \"Smith, J.\",\n    month  = jul,\n}\n"
+	if buf.String() != expect {
+		t.Errorf("got\n%s\nexpected\n%s", buf.String(), expect)
+	}
+}
+
+func TestEntryBuilderValidate(t *testing.T) {
+	b := NewEntry("article", "smith2020").Set("author", "Smith, J.").Set("title", "X").Set("year", "2020")
+	if _, err := b.Build(); err != nil {
+		t.Errorf("unexpected error without validation: %v", err)
+	}
+	entry, err := b.Validate(&Validator{RequiredFields: true}).Build()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "journal" {
+		t.Errorf("got error %v, expected missing journal", err)
+	}
+	if entry == nil {
+		t.Errorf("expected entry with validation errors")
+	}
+
+	if _, err := NewEntry("article", "bad key").Build(); err == nil {
+		t.Errorf("expected error for invalid key")
+	}
+	if _, err := NewEntry("", "a").Build(); err != ErrInvalidType {
+		t.Errorf("got error %v, expected ErrInvalidType", err)
+	}
+}
@@ -0,0 +1,67 @@
+package bibtex
+
+import "strings"
+
+// DefaultFieldAliases maps common alternative field names, in lowercase, to
+// their canonical names. It is used by CanonicalizeFields when no table is
+// given.
+var DefaultFieldAliases = map[string]string{
+	"link":    "url",
+	"ee":      "doi",
+	"keyword": "keywords",
+	"authors": "author",
+	"editors": "editor",
+}
+
+// FieldConflict is a policy for a field given under both its canonical name
+// and an alias.
+type FieldConflict int
+
+const (
+	// FieldConflictKeepCanonical keeps the value of the field with the
+	// canonical name, dropping the alias.
+	FieldConflictKeepCanonical FieldConflict = iota
+	// FieldConflictKeepAlias replaces the value of the canonical field with
+	// that of the alias.
+	FieldConflictKeepAlias
+)
+
+// CanonicalizeFields renames the fields of the entry to lowercase, and the
+// names in aliases to the canonical names they map to. Alias names are
+// matched ignoring case, and a nil table is DefaultFieldAliases. Where several
+// fields map to the same name, policy decides which value is kept; between two
+// aliases, or two case variants, the first is kept. Field order is preserved.
+func (entry *BibEntry) CanonicalizeFields(aliases map[string]string, policy FieldConflict) {
+	if aliases == nil {
+		aliases = DefaultFieldAliases
+	}
+	fields := make(map[string]BibString, len(entry.Fields))
+	canonical := map[string]bool{} // Value is from the field of that name.
+	var order []string
+	for _, name := range entry.FieldNamesInOrder() {
+		value := entry.Fields[name]
+		lower := strings.ToLower(name)
+		target := lower
+		if alias, ok := aliases[lower]; ok {
+			target = alias
+		}
+		exact := lower == target
+		if _, ok := fields[target]; !ok {
+			order = append(order, target)
+		} else if !(policy == FieldConflictKeepCanonical && exact && !canonical[target] ||
+			policy == FieldConflictKeepAlias && !exact && canonical[target]) {
+			continue
+		}
+		fields[target] = value
+		canonical[target] = exact
+	}
+	entry.Fields = fields
+	entry.order = order
+}
+
+// CanonicalizeFields applies BibEntry.CanonicalizeFields to all entries.
+func (bib *BibTex) CanonicalizeFields(aliases map[string]string, policy FieldConflict) {
+	for _, entry := range bib.Entries {
+		entry.CanonicalizeFields(aliases, policy)
+	}
+}
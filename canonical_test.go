@@ -0,0 +1,51 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalizeFields(t *testing.T) {
+	entry := MustParse(t, `@article{a, Title = {T}, EE = {10.1000/182}, link = {http://example.com}}`).Entries[0]
+	entry.CanonicalizeFields(nil, FieldConflictKeepCanonical)
+	if got := strings.Join(entry.FieldNamesInOrder(), ","); got != "title,doi,url" {
+		t.Errorf("got fields %s", got)
+	}
+	AssertFields(t, entry, map[string]string{
+		"title": "T",
+		"doi":   "10.1000/182",
+		"url":   "http://example.com",
+	})
+}
+
+func TestCanonicalizeFieldsConflict(t *testing.T) {
+	src := `@article{a, ee = {10.1000/alias}, doi = {10.1000/canonical}}`
+	cases := map[FieldConflict]string{
+		FieldConflictKeepCanonical: "10.1000/canonical",
+		FieldConflictKeepAlias:     "10.1000/alias",
+	}
+	for policy, expect := range cases {
+		entry := MustParse(t, src).Entries[0]
+		entry.CanonicalizeFields(nil, policy)
+		AssertFields(t, entry, map[string]string{"doi": expect})
+	}
+}
+
+func TestCanonicalizeFieldsConflictCase(t *testing.T) {
+	src := `@article{a, ee = {10.1000/alias}, DOI = {10.1000/canonical}}`
+	cases := map[FieldConflict]string{
+		FieldConflictKeepCanonical: "10.1000/canonical",
+		FieldConflictKeepAlias:     "10.1000/alias",
+	}
+	for policy, expect := range cases {
+		entry := MustParse(t, src).Entries[0]
+		entry.CanonicalizeFields(nil, policy)
+		AssertFields(t, entry, map[string]string{"doi": expect})
+	}
+}
+
+func TestCanonicalizeFieldsTable(t *testing.T) {
+	bib := MustParse(t, `@misc{a, howpublished = {Online}, URL = {http://example.com}}`)
+	bib.CanonicalizeFields(map[string]string{"howpublished": "note"}, FieldConflictKeepCanonical)
+	AssertFields(t, bib.Entries[0], map[string]string{"note": "Online", "url": "http://example.com"})
+}
@@ -0,0 +1,69 @@
+package bibtex
+
+import "strings"
+
+// Citation is a plain text summary of an entry for display, with LaTeX
+// decoded. It is the data passed to output templates.
+type Citation struct {
+	Key     string
+	Type    string
+	Authors string // Authors separated by commas.
+	Title   string
+	Venue   string // Journal, book title or publisher.
+	Year    string
+	DOI     string
+	URL     string // Link to the entry, preferring the DOI.
+}
+
+// NewCitation summarises an entry for display.
+func NewCitation(entry *BibEntry) *Citation {
+	c := &Citation{
+		Key:     entry.CiteName,
+		Type:    entry.Type,
+		Authors: plainNames(entry, "author"),
+		Title:   plainField(entry, "title"),
+		Year:    plainField(entry, "year"),
+		DOI:     plainField(entry, "doi"),
+		URL:     plainField(entry, "url"),
+	}
+	for _, venue := range []string{"journal", "booktitle", "publisher", "school", "institution"} {
+		if c.Venue = plainField(entry, venue); c.Venue != "" {
+			break
+		}
+	}
+	if c.DOI != "" {
+		c.URL = "https://doi.org/" + c.DOI
+	}
+	return c
+}
+
+// plainField returns the value of a field as plain text.
+func plainField(entry *BibEntry, field string) string {
+	value, ok := entry.Fields[field]
+	if !ok {
+		return ""
+	}
+	return collapseSpace(DecodeLaTeX(value.String()))
+}
+
+// plainNames returns a list of names as plain text, separated by commas.
+func plainNames(entry *BibEntry, field string) string {
+	value, ok := entry.Fields[field]
+	if !ok {
+		return ""
+	}
+	var names []string
+	for _, name := range splitNames(value.String()) {
+		names = append(names, collapseSpace(DecodeLaTeX(name)))
+	}
+	return strings.Join(names, ", ")
+}
+
+// sentence terminates s with a full stop, unless it already ends with
+// punctuation.
+func sentence(s string) string {
+	if strings.HasSuffix(s, ".") || strings.HasSuffix(s, "?") || strings.HasSuffix(s, "!") {
+		return s
+	}
+	return s + "."
+}
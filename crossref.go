@@ -0,0 +1,156 @@
+package bibtex
+
+import "strings"
+
+// CrossrefMode is a set of rules for inheriting fields through crossref.
+type CrossrefMode int
+
+const (
+	// CrossrefBibTeX copies the fields of the parent to the child under the
+	// same names, as BibTeX does.
+	CrossrefBibTeX CrossrefMode = iota
+	// CrossrefBibLaTeX applies the default biblatex inheritance rules, which
+	// rename some fields by entry type. For example, the title of a @book
+	// becomes the booktitle of an @inbook.
+	CrossrefBibLaTeX
+)
+
+// inheritRule renames fields inherited from parents of the given types by
+// children of the given types. A field that maps to no names is not
+// inherited.
+type inheritRule struct {
+	Parents  []string
+	Children []string
+	Fields   map[string][]string
+}
+
+// noInherit are the fields that are never inherited, in either mode.
+var noInherit = map[string]bool{
+	"ids": true, "crossref": true, "xref": true, "xdata": true, "entryset": true,
+	"entrysubtype": true, "execute": true, "label": true, "options": true,
+	"presort": true, "related": true, "relatedoptions": true, "relatedstring": true,
+	"relatedtype": true, "shorthand": true, "shorthandintro": true, "sortkey": true,
+}
+
+// inheritTitles returns the renaming of the title fields of a parent with the
+// given prefix, such as booktitle for a book. Short and sorting titles are not
+// inherited.
+func inheritTitles(prefix string) map[string][]string {
+	return map[string][]string{
+		"title":          {prefix + "title"},
+		"subtitle":       {prefix + "subtitle"},
+		"titleaddon":     {prefix + "titleaddon"},
+		"shorttitle":     nil,
+		"sorttitle":      nil,
+		"indextitle":     nil,
+		"indexsorttitle": nil,
+	}
+}
+
+// biblatexRules are the default inheritance rules of biblatex.
+var biblatexRules = []inheritRule{
+	{
+		Parents:  []string{"mvbook", "book"},
+		Children: []string{"inbook", "bookinbook", "suppbook"},
+		Fields:   map[string][]string{"author": {"author", "bookauthor"}},
+	},
+	{
+		Parents:  []string{"mvbook"},
+		Children: []string{"book", "inbook", "bookinbook", "suppbook"},
+		Fields:   inheritTitles("main"),
+	},
+	{
+		Parents:  []string{"mvcollection", "mvreference"},
+		Children: []string{"collection", "reference", "incollection", "inreference", "suppcollection"},
+		Fields:   inheritTitles("main"),
+	},
+	{
+		Parents:  []string{"mvproceedings"},
+		Children: []string{"proceedings", "inproceedings"},
+		Fields:   inheritTitles("main"),
+	},
+	{
+		Parents:  []string{"book"},
+		Children: []string{"inbook", "bookinbook", "suppbook"},
+		Fields:   inheritTitles("book"),
+	},
+	{
+		Parents:  []string{"collection", "reference"},
+		Children: []string{"incollection", "inreference", "suppcollection"},
+		Fields:   inheritTitles("book"),
+	},
+	{
+		Parents:  []string{"proceedings"},
+		Children: []string{"inproceedings"},
+		Fields:   inheritTitles("book"),
+	},
+	{
+		Parents:  []string{"periodical"},
+		Children: []string{"article", "suppperiodical"},
+		Fields:   inheritTitles("journal"),
+	},
+}
+
+// ResolveCrossrefs fills in the fields that each entry inherits from its
+// crossref parent, in place, using the rules of the given mode. Fields already
+// present in an entry are kept. Parents that have parents of their own are
+// resolved first.
+func (bib *BibTex) ResolveCrossrefs(mode CrossrefMode) {
+	resolved := map[*BibEntry]bool{}
+	var resolve func(entry *BibEntry)
+	resolve = func(entry *BibEntry) {
+		if resolved[entry] {
+			return
+		}
+		resolved[entry] = true // Marked first, to stop at cycles.
+		ref, ok := entry.Get("crossref")
+		if !ok {
+			return
+		}
+		parent := bib.ByKey(strings.TrimSpace(ref.String()))
+		if parent == nil {
+			return
+		}
+		resolve(parent)
+		for _, key := range parent.FieldNamesInOrder() {
+			if noInherit[strings.ToLower(key)] {
+				continue
+			}
+			for _, target := range inheritedNames(mode, parent.Type, entry.Type, strings.ToLower(key)) {
+				if _, ok := entry.Get(target); !ok {
+					entry.AddField(target, copyValue(parent.Fields[key]))
+				}
+			}
+		}
+	}
+	for _, entry := range bib.Entries {
+		resolve(entry)
+	}
+}
+
+// copyValue returns value with composites copied, so that appending to the
+// value of a child does not change its parent.
+func copyValue(value BibString) BibString {
+	if comp, ok := value.(*BibComposite); ok {
+		c := append(BibComposite(nil), *comp...)
+		return &c
+	}
+	return value
+}
+
+// inheritedNames returns the names under which a child inherits the field of
+// its parent.
+func inheritedNames(mode CrossrefMode, parentType, childType, field string) []string {
+	if mode != CrossrefBibLaTeX {
+		return []string{field}
+	}
+	for _, rule := range biblatexRules {
+		if !containsFold(rule.Parents, parentType) || !containsFold(rule.Children, childType) {
+			continue
+		}
+		if names, ok := rule.Fields[field]; ok {
+			return names
+		}
+	}
+	return []string{field}
+}
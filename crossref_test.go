@@ -0,0 +1,86 @@
+package bibtex
+
+import "testing"
+
+const crossrefSrc = `
+@inbook{chapter, crossref = {book}, title = {Chapter}, pages = {1--10}}
+@book{book, author = {Doe, Jane}, title = {Book}, subtitle = {Sub}, shorttitle = {B}, publisher = {P}, year = 2020}
+`
+
+func TestResolveCrossrefsBibTeX(t *testing.T) {
+	bib := MustParse(t, crossrefSrc)
+	bib.ResolveCrossrefs(CrossrefBibTeX)
+	AssertFields(t, bib.Entries[0], map[string]string{
+		"crossref":   "book",
+		"title":      "Chapter",
+		"pages":      "1--10",
+		"author":     "Doe, Jane",
+		"subtitle":   "Sub",
+		"shorttitle": "B",
+		"publisher":  "P",
+		"year":       "2020",
+	})
+}
+
+func TestResolveCrossrefsBibLaTeX(t *testing.T) {
+	bib := MustParse(t, crossrefSrc)
+	bib.ResolveCrossrefs(CrossrefBibLaTeX)
+	AssertFields(t, bib.Entries[0], map[string]string{
+		"crossref":     "book",
+		"title":        "Chapter",
+		"pages":        "1--10",
+		"author":       "Doe, Jane",
+		"bookauthor":   "Doe, Jane",
+		"booktitle":    "Book",
+		"booksubtitle": "Sub",
+		"publisher":    "P",
+		"year":         "2020",
+	})
+}
+
+func TestResolveCrossrefsChain(t *testing.T) {
+	bib := MustParse(t, `
+@inproceedings{paper, crossref = {proc}, title = {Paper}}
+@proceedings{proc, crossref = {series}, title = {Proceedings}}
+@mvproceedings{series, title = {Series}, publisher = {P}}
+@misc{loop, crossref = {loop}}
+`)
+	bib.ResolveCrossrefs(CrossrefBibLaTeX)
+	AssertFields(t, bib.Entries[0], map[string]string{
+		"crossref":  "proc",
+		"title":     "Paper",
+		"booktitle": "Proceedings",
+		"maintitle": "Series",
+		"publisher": "P",
+	})
+}
+
+func TestResolveCrossrefsFieldCase(t *testing.T) {
+	bib := MustParse(t, `
+@inbook{c, Crossref = {p}}
+@book{p, publisher = {P}}
+`)
+	bib.ResolveCrossrefs(CrossrefBibTeX)
+	AssertFields(t, bib.Entries[0], map[string]string{
+		"crossref":  "p",
+		"publisher": "P",
+	})
+}
+
+// Tests that changing an inherited composite value leaves the parent alone.
+func TestResolveCrossrefsCopiesComposites(t *testing.T) {
+	bib := MustParse(t, `
+@string{pub = "P"}
+@inbook{c, crossref = {p}}
+@book{p, publisher = pub # { Press}}
+`)
+	bib.ResolveCrossrefs(CrossrefBibTeX)
+	child, parent := bib.Entries[0], bib.Entries[1]
+	child.Fields["publisher"].(*BibComposite).Append(NewBibConst(" Ltd"))
+	if got := parent.Fields["publisher"].String(); got != "P Press" {
+		t.Errorf("parent publisher changed to %q", got)
+	}
+	if got := child.Fields["publisher"].String(); got != "P Press Ltd" {
+		t.Errorf("got child publisher %q", got)
+	}
+}
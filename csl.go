@@ -0,0 +1,319 @@
+package bibtex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// cslTypes maps BibTeX entry types to CSL item types.
+var cslTypes = map[string]string{
+	"article":       "article-journal",
+	"book":          "book",
+	"booklet":       "pamphlet",
+	"inbook":        "chapter",
+	"incollection":  "chapter",
+	"inproceedings": "paper-conference",
+	"manual":        "report",
+	"mastersthesis": "thesis",
+	"misc":          "document",
+	"online":        "webpage",
+	"phdthesis":     "thesis",
+	"proceedings":   "book",
+	"techreport":    "report",
+	"unpublished":   "manuscript",
+}
+
+// bibtexTypes maps CSL item types to BibTeX entry types, where the reverse of
+// cslTypes is ambiguous or missing.
+var bibtexTypes = map[string]string{
+	"article":           "article",
+	"article-journal":   "article",
+	"article-magazine":  "article",
+	"article-newspaper": "article",
+	"book":              "book",
+	"chapter":           "incollection",
+	"document":          "misc",
+	"manuscript":        "unpublished",
+	"pamphlet":          "booklet",
+	"paper-conference":  "inproceedings",
+	"report":            "techreport",
+	"thesis":            "phdthesis",
+	"webpage":           "online",
+}
+
+// RegisterCSLType maps the BibTeX entry type entryType to the CSL item type
+// cslType, and back, overriding any built-in mapping of either. It is intended
+// for custom or niche types, and should be called during initialization, as it
+// is not safe to call concurrently with conversions.
+func RegisterCSLType(entryType, cslType string) {
+	entryType = strings.ToLower(entryType)
+	cslTypes[entryType] = cslType
+	bibtexTypes[cslType] = entryType
+}
+
+// cslItem is an item in CSL-JSON.
+type cslItem struct {
+	ID             cslString `json:"id"`
+	Type           string    `json:"type"`
+	Title          cslString `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Editor         []cslName `json:"editor,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	ContainerTitle cslString `json:"container-title,omitempty"`
+	Volume         cslString `json:"volume,omitempty"`
+	Issue          cslString `json:"issue,omitempty"`
+	Page           cslString `json:"page,omitempty"`
+	Publisher      cslString `json:"publisher,omitempty"`
+	PublisherPlace cslString `json:"publisher-place,omitempty"`
+	DOI            cslString `json:"DOI,omitempty"`
+	URL            cslString `json:"URL,omitempty"`
+	ISBN           cslString `json:"ISBN,omitempty"`
+	Abstract       cslString `json:"abstract,omitempty"`
+	Note           cslString `json:"note,omitempty"`
+}
+
+// cslName is a name in CSL-JSON.
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Suffix  string `json:"suffix,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// cslDate is a date in CSL-JSON.
+type cslDate struct {
+	DateParts [][]cslString `json:"date-parts"`
+}
+
+// cslString is a CSL-JSON value that may be given as a string or a number.
+type cslString string
+
+func (s *cslString) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		*s = cslString(str)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*s = cslString(n.String())
+	return nil
+}
+
+// ToCSLJSON converts the bibliography to an array of CSL-JSON items. Field
+// values are converted to plain text.
+func (bib *BibTex) ToCSLJSON() ([]byte, error) {
+	items := []*cslItem{}
+	for _, entry := range bib.Entries {
+		items = append(items, toCSLItem(entry))
+	}
+	return json.MarshalIndent(items, "", "  ")
+}
+
+func toCSLItem(entry *BibEntry) *cslItem {
+	typ, ok := cslTypes[entry.Type]
+	if !ok {
+		typ = "document"
+	}
+	item := &cslItem{
+		ID:       cslString(entry.CiteName),
+		Type:     typ,
+		Title:    cslString(plainField(entry, "title")),
+		Author:   toCSLNames(entry.Names("author")),
+		Editor:   toCSLNames(entry.Names("editor")),
+		Volume:   cslString(plainField(entry, "volume")),
+		Issue:    cslString(plainField(entry, "number")),
+		DOI:      cslString(plainField(entry, "doi")),
+		URL:      cslString(plainField(entry, "url")),
+		ISBN:     cslString(plainField(entry, "isbn")),
+		Abstract: cslString(plainField(entry, "abstract")),
+		Note:     cslString(plainField(entry, "note")),
+	}
+	for _, field := range []string{"journal", "booktitle"} {
+		if v := plainField(entry, field); v != "" {
+			item.ContainerTitle = cslString(v)
+			break
+		}
+	}
+	for _, field := range []string{"publisher", "school", "institution", "organization"} {
+		if v := plainField(entry, field); v != "" {
+			item.Publisher = cslString(v)
+			break
+		}
+	}
+	item.PublisherPlace = cslString(plainField(entry, "address"))
+	if pages := plainField(entry, "pages"); pages != "" {
+		if start, end, ok := parsePageRange(pages); ok && end != "" {
+			pages = start + "-" + end
+		}
+		item.Page = cslString(pages)
+	}
+	if year := plainField(entry, "year"); year != "" {
+		parts := []cslString{cslString(year)}
+		if m, ok := entry.Month(); ok {
+			parts = append(parts, cslString(strconv.Itoa(int(m))))
+		}
+		item.Issued = &cslDate{DateParts: [][]cslString{parts}}
+	}
+	return item
+}
+
+func toCSLNames(names []Name) []cslName {
+	var out []cslName
+	for _, n := range names {
+		if n.First == "" && n.Von == "" && n.Jr == "" && strings.HasPrefix(n.Last, "{") {
+			out = append(out, cslName{Literal: DecodeLaTeX(n.Last)})
+			continue
+		}
+		family := n.Last
+		if n.Von != "" {
+			family = n.Von + " " + family
+		}
+		out = append(out, cslName{
+			Family: DecodeLaTeX(family),
+			Given:  DecodeLaTeX(n.First),
+			Suffix: DecodeLaTeX(n.Jr),
+		})
+	}
+	return out
+}
+
+// FromCSLJSON converts an array of CSL-JSON items to a bibliography. Items
+// whose id is not usable as a citation key are given a generated key.
+func FromCSLJSON(data []byte) (*BibTex, error) {
+	var items []*cslItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("csl-json: %w", err)
+	}
+	bib := NewBibTex()
+	used := map[string]bool{}
+	for _, item := range items {
+		entry := fromCSLItem(item)
+		key := string(item.ID)
+		if !ValidKey(key) || used[key] {
+			key = uniqueKey(cslKeyBase(item), used)
+		}
+		used[key] = true
+		entry.CiteName = key
+		bib.AddEntry(entry)
+	}
+	return bib, nil
+}
+
+func fromCSLItem(item *cslItem) *BibEntry {
+	typ, ok := bibtexTypes[item.Type]
+	if !ok {
+		typ = "misc"
+	}
+	entry := NewBibEntry(typ, "")
+	set := func(field string, value cslString) {
+		if value == "" {
+			return
+		}
+		s := string(value)
+		if !containsFold(verbatimFields, field) {
+			s = escapeText(s)
+		}
+		entry.AddField(field, NewBibConst(s))
+	}
+	setNames := func(field string, names []cslName) {
+		if s := fromCSLNames(names); s != "" {
+			entry.AddField(field, NewBibConst(s))
+		}
+	}
+	set("title", item.Title)
+	setNames("author", item.Author)
+	setNames("editor", item.Editor)
+	switch typ {
+	case "inproceedings", "incollection", "inbook":
+		set("booktitle", item.ContainerTitle)
+	default:
+		set("journal", item.ContainerTitle)
+	}
+	switch typ {
+	case "phdthesis", "mastersthesis":
+		set("school", item.Publisher)
+	case "techreport":
+		set("institution", item.Publisher)
+	default:
+		set("publisher", item.Publisher)
+	}
+	set("address", item.PublisherPlace)
+	set("volume", item.Volume)
+	set("number", item.Issue)
+	set("pages", cslString(NormalizePages(string(item.Page))))
+	set("doi", item.DOI)
+	set("url", item.URL)
+	set("isbn", item.ISBN)
+	set("abstract", item.Abstract)
+	set("note", item.Note)
+	if item.Issued != nil && len(item.Issued.DateParts) > 0 {
+		parts := item.Issued.DateParts[0]
+		if len(parts) > 0 {
+			set("year", parts[0])
+		}
+		if len(parts) > 1 {
+			set("month", parts[1])
+		}
+	}
+	return entry
+}
+
+func fromCSLNames(names []cslName) string {
+	var out []string
+	for _, n := range names {
+		switch {
+		case n.Literal != "":
+			out = append(out, "{"+escapeText(n.Literal)+"}")
+		case n.Given == "" && n.Suffix == "":
+			out = append(out, escapeText(n.Family))
+		case n.Suffix != "":
+			out = append(out, escapeText(n.Family)+", "+escapeText(n.Suffix)+", "+escapeText(n.Given))
+		default:
+			out = append(out, escapeText(n.Family)+", "+escapeText(n.Given))
+		}
+	}
+	return strings.Join(out, " and ")
+}
+
+// cslKeyBase builds a citation key from the first author's family name and
+// the year of an item.
+func cslKeyBase(item *cslItem) string {
+	var buf bytes.Buffer
+	if len(item.Author) > 0 {
+		name := item.Author[0].Family
+		if name == "" {
+			name = item.Author[0].Literal
+		}
+		for _, ch := range ASCIIFold(name) {
+			if ch < unicode.MaxASCII && (unicode.IsLetter(ch) || unicode.IsDigit(ch)) {
+				buf.WriteRune(unicode.ToLower(ch))
+			}
+		}
+	}
+	if item.Issued != nil && len(item.Issued.DateParts) > 0 && len(item.Issued.DateParts[0]) > 0 {
+		buf.WriteString(string(item.Issued.DateParts[0][0]))
+	}
+	if buf.Len() == 0 {
+		return "item"
+	}
+	return buf.String()
+}
+
+// uniqueKey returns base, or base with a numeric suffix if it is used.
+func uniqueKey(base string, used map[string]bool) string {
+	key := base
+	for i := 2; used[key]; i++ {
+		key = base + strconv.Itoa(i)
+	}
+	return key
+}
@@ -0,0 +1,173 @@
+package bibtex
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const cslFixture = `[
+  {
+    "id": "smith2020",
+    "type": "article-journal",
+    "title": "Fish and Chips",
+    "author": [
+      {"family": "Smith", "given": "Jane"},
+      {"family": "Müller", "given": "Jörg"},
+      {"literal": "The Fish Consortium"}
+    ],
+    "issued": {"date-parts": [[2020, 7]]},
+    "container-title": "Journal of Food",
+    "volume": 12,
+    "issue": "3",
+    "page": "45-67",
+    "DOI": "10.1000/182"
+  },
+  {
+    "id": "has space",
+    "type": "paper-conference",
+    "title": "Proceedings Paper",
+    "author": [{"family": "Doe", "given": "John"}],
+    "issued": {"date-parts": [["2019"]]},
+    "container-title": "Proc. Conference"
+  }
+]`
+
+func TestFromCSLJSON(t *testing.T) {
+	bib, err := FromCSLJSON([]byte(cslFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bib.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(bib.Entries))
+	}
+
+	a := bib.Entries[0]
+	expect := map[string]string{
+		"title":   "Fish and Chips",
+		"author":  "Smith, Jane and Müller, Jörg and {The Fish Consortium}",
+		"year":    "2020",
+		"month":   "7",
+		"journal": "Journal of Food",
+		"volume":  "12",
+		"number":  "3",
+		"pages":   "45--67",
+		"doi":     "10.1000/182",
+	}
+	if a.Type != "article" || a.CiteName != "smith2020" {
+		t.Errorf("got @%s{%s}", a.Type, a.CiteName)
+	}
+	for field, value := range expect {
+		if got := fieldString(a, field); got != value {
+			t.Errorf("%s: got %q, expected %q", field, got, value)
+		}
+	}
+
+	b := bib.Entries[1]
+	if b.Type != "inproceedings" || b.CiteName != "doe2019" || fieldString(b, "booktitle") != "Proc. Conference" {
+		t.Errorf("unexpected entry %+v", b)
+	}
+}
+
+// Tests plain text with LaTeX special characters survives conversion to
+// BibTeX, formatting and parsing.
+func TestFromCSLJSONSpecialCharacters(t *testing.T) {
+	const title = `Fish & Chips: 50% {off #1 \ 100$_x`
+	data, err := json.Marshal([]map[string]interface{}{{
+		"id":     "fish",
+		"type":   "article-journal",
+		"title":  title,
+		"author": []map[string]string{{"literal": "Smith & Sons {Ltd"}},
+		"URL":    "http://x.com/?a=1&b=50%",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bib, err := FromCSLJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := (&Formatter{}).Format(&buf, bib); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("parse formatted entry: %v", err)
+	}
+	entry := parsed.Entries[0]
+	if got := plainField(entry, "title"); got != title {
+		t.Errorf("got title %q, expected %q", got, title)
+	}
+	if got := plainField(entry, "author"); got != "Smith & Sons {Ltd" {
+		t.Errorf("got author %q", got)
+	}
+	if got := fieldString(entry, "url"); got != "http://x.com/?a=1&b=50%" {
+		t.Errorf("got url %q", got)
+	}
+}
+
+// Tests CSL-JSON round trips through the model, preserving core fields.
+func TestCSLJSONRoundTrip(t *testing.T) {
+	bib, err := FromCSLJSON([]byte(cslFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := bib.ToCSLJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expect, got []map[string]interface{}
+	if err := json.Unmarshal([]byte(cslFixture), &expect); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d items, got %d", len(expect), len(got))
+	}
+
+	// Numbers are converted to strings, and generated keys replace bad ids.
+	expect[0]["volume"] = "12"
+	expect[0]["issued"] = map[string]interface{}{"date-parts": []interface{}{[]interface{}{"2020", "7"}}}
+	expect[1]["id"] = "doe2019"
+	for i := range expect {
+		for key, value := range expect[i] {
+			if !reflect.DeepEqual(got[i][key], value) {
+				t.Errorf("item %d: %s: got %v, expected %v", i, key, got[i][key], value)
+			}
+		}
+	}
+}
+
+func TestRegisterCSLType(t *testing.T) {
+	RegisterCSLType("Dataset", "dataset")
+	defer func() {
+		delete(cslTypes, "dataset")
+		delete(bibtexTypes, "dataset")
+	}()
+
+	bib := MustParse(t, `@dataset{d, title = {Measurements}}`)
+	data, err := bib.ToCSLJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0]["type"] != "dataset" {
+		t.Fatalf("unexpected items %s", data)
+	}
+
+	back, err := FromCSLJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := back.Entries[0].Type; typ != "dataset" {
+		t.Errorf("got type %q, expected dataset", typ)
+	}
+}
@@ -0,0 +1,102 @@
+package bibtex
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Decoder reads entries from an input stream one at a time, so that a large
+// file can be processed without holding every entry in memory. String
+// variables and preambles are kept, as entries may refer to them. A string
+// variable used before its definition is empty until the end of the input.
+type Decoder struct {
+	// Validator, if set, checks each entry as it is decoded.
+	Validator *Validator
+
+	l     *Lexer
+	items chan decoded
+	done  chan struct{}
+	err   error // Error that ended decoding.
+}
+
+// decoded is an entry or the error that ended decoding.
+type decoded struct {
+	entry *BibEntry
+	err   error
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return (&Parser{}).NewDecoder(r)
+}
+
+// NewDecoder returns a decoder reading from r with the options of p. A leading
+// UTF-8 byte order mark is skipped.
+func (p *Parser) NewDecoder(r io.Reader) *Decoder {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(b, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	l := NewLexer(br)
+	l.parser = p
+	l.scanner.Logger = p.Logger
+	l.scanner.MaxValueLength = p.MaxValueLength
+	return &Decoder{l: l, done: make(chan struct{})}
+}
+
+// Next returns the next entry, along with any issues the Validator finds in
+// it. At the end of the input Next returns io.EOF. A parse error is returned
+// after the entries before it.
+func (d *Decoder) Next() (*BibEntry, []*ValidationError, error) {
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	if d.items == nil {
+		d.start()
+	}
+	item := <-d.items
+	if item.err != nil {
+		d.err = item.err
+		return nil, nil, item.err
+	}
+	var issues []*ValidationError
+	if d.Validator != nil {
+		issues = d.Validator.ValidateEntry(item.entry)
+	}
+	return item.entry, issues, nil
+}
+
+// Close stops decoding. It must be called if Next is not called until it
+// returns an error.
+func (d *Decoder) Close() {
+	if d.err == nil {
+		d.err = io.EOF
+		close(d.done)
+	}
+}
+
+// start runs the parser, which passes entries to Next as they are parsed.
+func (d *Decoder) start() {
+	d.items = make(chan decoded)
+	d.l.emit = func(entry *BibEntry) bool {
+		select {
+		case d.items <- decoded{entry: entry}:
+			return true
+		case <-d.done:
+			return false
+		}
+	}
+	go func() {
+		bibtexParse(d.l)
+		err := io.EOF
+		select {
+		case err = <-d.l.Errors:
+		default:
+		}
+		select {
+		case d.items <- decoded{err: err}:
+		case <-d.done:
+		}
+	}()
+}
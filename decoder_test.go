@@ -0,0 +1,61 @@
+package bibtex
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderValidation(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`
+@string{acm = {ACM}}
+@article{ok, author = {A. Author}, title = {T}, journal = acm, year = 2020}
+@article{missing, author = {B. Author}, title = {U}, year = 2021}
+@misc{last, title = {V}}
+`))
+	d.Validator = &Validator{RequiredFields: true}
+	var keys []string
+	for {
+		entry, issues, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, entry.CiteName)
+		if entry.CiteName == "missing" {
+			if len(issues) != 1 || issues[0].Key != "missing" || issues[0].Message != "missing required field journal or journaltitle" {
+				t.Errorf("unexpected issues %v", issues)
+			}
+		} else if len(issues) != 0 {
+			t.Errorf("%s: unexpected issues %v", entry.CiteName, issues)
+		}
+	}
+	if got := strings.Join(keys, ","); got != "ok,missing,last" {
+		t.Errorf("decoded %s", got)
+	}
+}
+
+func TestDecoderError(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`@misc{a, title = {A}} @misc{b, title = {B}`))
+	if entry, _, err := d.Next(); err != nil || entry.CiteName != "a" {
+		t.Fatalf("got %v, %v", entry, err)
+	}
+	var perr *ErrParse
+	if _, _, err := d.Next(); !errors.As(err, &perr) {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestDecoderClose(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`@misc{a, title = {A}} @misc{b, title = {B}}`))
+	if _, _, err := d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	if _, _, err := d.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after Close, got %v", err)
+	}
+}
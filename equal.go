@@ -0,0 +1,63 @@
+package bibtex
+
+import "strings"
+
+// EntriesEqual reports whether two entries are semantically equal. Types and
+// field names are compared case-insensitively, and field values are compared
+// after decoding LaTeX and collapsing whitespace. It is useful in tests, where
+// differently formatted but equivalent entries should compare equal.
+func EntriesEqual(a, b *BibEntry) bool {
+	if !strings.EqualFold(a.Type, b.Type) || a.CiteName != b.CiteName {
+		return false
+	}
+	return fieldsEqual(a, b, func(s string) string {
+		return collapseSpace(DecodeLaTeX(s))
+	})
+}
+
+// fieldsEqual reports whether two entries have the same fields, with names
+// compared case-insensitively and values compared after normalize.
+func fieldsEqual(a, b *BibEntry, normalize func(string) string) bool {
+	fa, fb := normalizedFields(a, normalize), normalizedFields(b, normalize)
+	if len(fa) != len(fb) {
+		return false
+	}
+	for key, value := range fa {
+		if other, ok := fb[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizedFields returns the fields of an entry with lowercase names and
+// values normalized by normalize.
+func normalizedFields(entry *BibEntry, normalize func(string) string) map[string]string {
+	fields := map[string]string{}
+	for key, value := range entry.Fields {
+		fields[strings.ToLower(strings.TrimSpace(key))] = normalize(value.String())
+	}
+	return fields
+}
+
+// Equal reports whether the entry is structurally equal to other: the same
+// type, with aliases resolved, the same citation key, and the same fields in
+// the same order. Field names are compared case-insensitively and values after
+// collapsing whitespace. Unlike EntriesEqual, LaTeX is not decoded and field
+// order matters. Source positions, leading comments and delimiters are
+// ignored.
+func (entry *BibEntry) Equal(other *BibEntry) bool {
+	if canonicalType(strings.ToLower(entry.Type)) != canonicalType(strings.ToLower(other.Type)) || entry.CiteName != other.CiteName {
+		return false
+	}
+	if !fieldsEqual(entry, other, collapseSpace) {
+		return false
+	}
+	na, nb := entry.FieldNamesInOrder(), other.FieldNamesInOrder()
+	for i := range na {
+		if !strings.EqualFold(strings.TrimSpace(na[i]), strings.TrimSpace(nb[i])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEntriesEqual(t *testing.T) {
+	a, err := Parse(strings.NewReader(`@Article{key,
+  Title = {The   {DNA}
+           Helix},
+  author = "M{\"u}ller, J.",
+  year = 2020
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse(strings.NewReader(`@article{key, year = {2020}, author = {Müller, J.}, title = "The DNA Helix"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EntriesEqual(a.Entries[0], b.Entries[0]) {
+		t.Errorf("expected entries to be equal")
+	}
+}
+
+func TestEntriesNotEqual(t *testing.T) {
+	base := func() *BibEntry {
+		entry := NewBibEntry("article", "key")
+		entry.AddField("title", NewBibConst("Title"))
+		return entry
+	}
+
+	value := base()
+	value.Fields["title"] = NewBibConst("Other")
+
+	extra := base()
+	extra.AddField("year", NewBibConst("2020"))
+
+	key := base()
+	key.CiteName = "other"
+
+	for _, other := range []*BibEntry{value, extra, key} {
+		if EntriesEqual(base(), other) {
+			t.Errorf("expected %v to differ", other)
+		}
+	}
+}
+
+func TestEntryEqual(t *testing.T) {
+	bib := MustParse(t, `
+% A comment.
+@Article{key,
+  title = {The   DNA
+           Helix},
+  Year = 2020
+}
+
+@article(key, title = "The DNA Helix", year = {2020})
+@www{site, url = {http://example.com}}
+@online{site, url = {http://example.com}}
+`)
+	a, b := bib.Entries[0], bib.Entries[1]
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Errorf("expected entries to be equal")
+	}
+	if !bib.Entries[2].Equal(bib.Entries[3]) {
+		t.Errorf("expected type aliases to be equal")
+	}
+
+	c := MustParse(t, `@article{key, year = 2021, title = {The DNA Helix}}`).Entries[0]
+	d := MustParse(t, `@article{key, year = 2020, title = {The {DNA} Helix}}`).Entries[0]
+	e := MustParse(t, `@article{key, year = 2020, title = {The DNA Helix}}`).Entries[0]
+	for _, other := range []*BibEntry{c, d, e} {
+		if a.Equal(other) {
+			t.Errorf("expected %v to differ", other)
+		}
+	}
+}
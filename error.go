@@ -7,17 +7,47 @@ import (
 
 var (
 	// ErrUnexpectedAtsign is an error for unexpected @ in {}.
+	//
+	// Deprecated: an @ in a braced value is taken literally, and this error is
+	// no longer returned.
 	ErrUnexpectedAtsign = errors.New("Unexpected @ sign")
 	// ErrUnknownStringVar is an error for looking up undefined string var.
 	ErrUnknownStringVar = errors.New("Unknown string variable")
+	// ErrRedefinedStringVar is an error for defining a string var twice.
+	ErrRedefinedStringVar = errors.New("Redefined string variable")
+	// ErrDuplicateField is an error for a field given twice in one entry.
+	ErrDuplicateField = errors.New("Duplicate field")
+	// ErrUnresolvedMacro is an alias of ErrUnknownStringVar.
+	ErrUnresolvedMacro = ErrUnknownStringVar
+	// ErrUnexpectedToken is an error for a token the grammar does not allow.
+	ErrUnexpectedToken = errors.New("Unexpected token")
+	// ErrUnbalancedBrace is an error for a value with unbalanced braces.
+	ErrUnbalancedBrace = errors.New("Unbalanced brace")
+	// ErrUnterminatedString is an error for a quoted value with no closing
+	// quote before the end of input.
+	ErrUnterminatedString = errors.New("Unterminated quoted string")
+	// ErrValueTooLong is an error for a value longer than the configured
+	// maximum, most likely because its closing delimiter is missing.
+	ErrValueTooLong = errors.New("Value too long")
+	// ErrUnknownKey is an error for looking up an undefined citation key.
+	ErrUnknownKey = errors.New("Unknown citation key")
+	// ErrDuplicateKey is an error for a citation key that is already in use.
+	ErrDuplicateKey = errors.New("Duplicate citation key")
 )
 
-// ErrParse is a parse error.
+// ErrParse is a parse error. It wraps one of the sentinel errors above, such as
+// ErrUnbalancedBrace, so that the kind of error may be tested with errors.Is.
 type ErrParse struct {
-	Pos TokenPos
-	Err string // Error string returned from parser.
+	Pos  TokenPos
+	Err  string // Error string returned from parser.
+	Kind error  // Sentinel error for the kind of failure.
 }
 
 func (e *ErrParse) Error() string {
 	return fmt.Sprintf("Parse failed at %s: %s", e.Pos, e.Err)
 }
+
+// Unwrap returns the kind of the error.
+func (e *ErrParse) Unwrap() error {
+	return e.Kind
+}
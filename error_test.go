@@ -0,0 +1,54 @@
+package bibtex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorKinds(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Parser Parser
+		Input  string
+		Kind   error
+	}{
+		{"unbalanced", Parser{}, `@misc{a, title = "{open`, ErrUnbalancedBrace},
+		{"token", Parser{}, `@misc{a, title = = {T}}`, ErrUnexpectedToken},
+		{"macro", Parser{}, `@misc{a, journal = jacm}`, ErrUnresolvedMacro},
+		{"string", Parser{StrictStrings: true}, `@string{j = {J}} @string{J = {K}}`, ErrRedefinedStringVar},
+		{"field", Parser{StrictFields: true}, `@misc{a, title = {A}, Title = {B}}`, ErrDuplicateField},
+		{"key", Parser{StrictKeys: true}, `@misc{a, title = {A}} @misc{A, title = {B}}`, ErrDuplicateKey},
+		{"length", Parser{MaxValueLength: 3}, `@misc{a, title = {Long}}`, ErrValueTooLong},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := c.Parser.Parse(strings.NewReader(c.Input))
+			if !errors.Is(err, c.Kind) {
+				t.Fatalf("got error %v, expected %v", err, c.Kind)
+			}
+			var perr *ErrParse
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected *ErrParse, got %T", err)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeyWarning(t *testing.T) {
+	bib := MustParse(t, "@misc{a, title = {A}}\n@misc{A, title = {B}}")
+	if len(bib.Entries) != 2 || len(bib.Warnings) != 1 {
+		t.Fatalf("got %d entries and warnings %v", len(bib.Entries), bib.Warnings)
+	}
+	var perr *ErrParse
+	if err := bib.Warnings[0]; !errors.Is(err, ErrDuplicateKey) || !errors.As(err, &perr) || perr.Pos.String() != "2:7" {
+		t.Errorf("got warning %v, expected ErrDuplicateKey at 2:7", err)
+	}
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+	bib := MustParse(t, `@misc{a, title = {A}} @misc{b, title = {B}}`)
+	if _, err := bib.RenameEntry("a", "b"); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("got error %v, expected ErrDuplicateKey", err)
+	}
+}
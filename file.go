@@ -0,0 +1,119 @@
+package bibtex
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// gzipMagic are the leading bytes of gzip-compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ParseFile parses the bibtex file at path. Gzip-compressed files are
+// decompressed transparently.
+func ParseFile(path string) (*BibTex, error) {
+	return (&Parser{}).ParseFile(path)
+}
+
+// ParseFile parses the bibtex file at path. Gzip-compressed files are
+// decompressed transparently.
+func (p *Parser) ParseFile(path string) (*BibTex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return p.parseFile(f, path)
+}
+
+// ParseFS parses the bibtex file name in fsys, such as an embed.FS, in the
+// same way as ParseFile.
+func ParseFS(fsys fs.FS, name string) (*BibTex, error) {
+	return (&Parser{}).ParseFS(fsys, name)
+}
+
+// ParseFS parses the bibtex file name in fsys, such as an embed.FS, in the
+// same way as ParseFile.
+func (p *Parser) ParseFS(fsys fs.FS, name string) (*BibTex, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return p.parseFile(f, name)
+}
+
+// parseFile parses from r, detecting gzip compression by the magic bytes or
+// the file extension of name.
+func (p *Parser) parseFile(r io.Reader, name string) (*BibTex, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(gzipMagic))
+	if filepath.Ext(name) == ".gz" || bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return p.Parse(zr)
+	}
+	return p.Parse(br)
+}
+
+// ParseFiles parses the given files concurrently, using at most concurrency
+// workers. It returns the parsed bibliographies and parse errors keyed by
+// path. A concurrency of less than one parses the files sequentially.
+func ParseFiles(paths []string, concurrency int) (map[string]*BibTex, map[string]error) {
+	return (&Parser{}).ParseFiles(paths, concurrency)
+}
+
+// ParseFiles parses the given files concurrently, using at most concurrency
+// workers. It returns the parsed bibliographies and parse errors keyed by
+// path. A concurrency of less than one parses the files sequentially.
+func (p *Parser) ParseFiles(paths []string, concurrency int) (map[string]*BibTex, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	type result struct {
+		path string
+		bib  *BibTex
+		err  error
+	}
+	jobs := make(chan string)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				bib, err := p.ParseFile(path)
+				results <- result{path: path, bib: bib, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, path := range paths {
+			jobs <- path
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	bibs := map[string]*BibTex{}
+	errs := map[string]error{}
+	for r := range results {
+		if r.err != nil {
+			errs[r.path] = r.err
+		} else {
+			bibs[r.path] = r.bib
+		}
+	}
+	return bibs, errs
+}
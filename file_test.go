@@ -0,0 +1,122 @@
+package bibtex
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// WriteGzip writes a gzip-compressed copy of src to path.
+func WriteGzip(t *testing.T, src, path string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseFileGzip(t *testing.T) {
+	src := "example/biblatex-examples.bib"
+	expect, err := ParseFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "bibtex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Detected by extension and by magic bytes.
+	for _, name := range []string{"refs.bib.gz", "refs.bib"} {
+		path := filepath.Join(dir, name)
+		WriteGzip(t, src, path)
+		bib, err := ParseFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		AssertEntryListsEqual(t, expect.Entries, bib.Entries)
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	paths, err := filepath.Glob("example/*.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = append(paths, "example/missing.bib")
+
+	bibs, errs := ParseFiles(paths, 4)
+	if len(errs) != 1 || errs["example/missing.bib"] == nil {
+		t.Errorf("expected an error for the missing file only, got %v", errs)
+	}
+	if len(bibs) != len(paths)-1 {
+		t.Fatalf("expected %d results, got %d", len(paths)-1, len(bibs))
+	}
+	for _, path := range paths[:len(paths)-1] {
+		expect, err := ParseFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertEntryListsEqual(t, expect.Entries, bibs[path].Entries)
+	}
+}
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"refs/default.bib": {Data: []byte(`@book{knuth, author = {Donald E. Knuth}, title = {The Art of Computer Programming}}`)},
+	}
+	bib, err := ParseFS(fsys, "refs/default.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bib.Entries) != 1 || bib.Entries[0].CiteName != "knuth" {
+		t.Errorf("unexpected entries %v", bib.Entries)
+	}
+	if _, err := ParseFS(fsys, "refs/missing.bib"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestParseFSByteOrderMark(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bom.bib": {Data: []byte("\ufeff@misc{a, title={T}}\n@misc{b, title={U}}")},
+	}
+	bib, err := ParseFS(fsys, "bom.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertOrder(t, bib.Entries, "a,b")
+	if _, err := ParseBytes([]byte("\ufeff@misc{a, title={T}}")); err != nil {
+		t.Errorf("ParseBytes with byte order mark: %v", err)
+	}
+}
+
+func TestDecoderByteOrderMark(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\ufeff@misc{a, title={T}}"))
+	defer d.Close()
+	entry, _, err := d.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.CiteName != "a" {
+		t.Errorf("got entry %q, expected a", entry.CiteName)
+	}
+}
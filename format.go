@@ -0,0 +1,493 @@
+package bibtex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formatter holds options for pretty printing a BibTex. The zero value formats
+// in the same way as PrettyString.
+type Formatter struct {
+	MonthFormat    MonthFormat     // Output format of the month field.
+	NormalizePages bool            // Write page ranges as 1--10.
+	Delimiters     DelimiterFormat // Brackets enclosing entries.
+	BlankLines     BlankLineFormat // Blank lines between entries.
+	LineEnding     LineEnding      // Newline sequence written.
+
+	// EscapeAmpersands writes bare & characters as \&, as LaTeX requires.
+	// Ampersands that are already escaped are left alone.
+	EscapeAmpersands bool
+
+	// NormalizeNames canonicalizes the separators between names in the
+	// author and editor fields. See NormalizeNames.
+	NormalizeNames bool
+
+	// NormalizeWhitespace collapses runs of whitespace in values to a single
+	// space, except in the fields listed in PreserveWhitespace. Line breaks in
+	// those fields are kept, and continuation lines are indented uniformly.
+	NormalizeWhitespace bool
+	// PreserveWhitespace lists the fields whose line breaks are kept by
+	// NormalizeWhitespace. If nil, note, annote and abstract are preserved.
+	PreserveWhitespace []string
+
+	// TrimTrailingPunctuation lists the fields from which a trailing period,
+	// comma or semicolon is removed, as styles add their own. See
+	// TrimTrailingPunctuation.
+	TrimTrailingPunctuation []string
+
+	// IncludeFields, if non-empty, restricts output to the listed fields.
+	// ExcludeFields omits the listed fields. Names are case-insensitive, and
+	// the bibliography is not modified.
+	IncludeFields []string
+	ExcludeFields []string
+	// DropEmpty omits fields whose value is empty or only whitespace.
+	DropEmpty bool
+
+	// TypeAliases writes entries with the type alias they were parsed with,
+	// such as @www, rather than the canonical type.
+	TypeAliases bool
+	// LowercaseTypes writes entry types in lowercase. By default parsed
+	// entries are written with the type in its original case, as in
+	// @InProceedings.
+	LowercaseTypes bool
+
+	// PreserveMacros writes values that use string variables as parsed, such
+	// as ieee # " Trans.", rather than writing their resolved value. The
+	// string variables are defined before the entries.
+	PreserveMacros bool
+
+	// Accents is how accented letters in values are written: as LaTeX
+	// commands, as Unicode, or as parsed. The url, doi and file fields are
+	// left unchanged.
+	Accents AccentMode
+
+	// UnbalancedBraces is how braces in values that have no match are
+	// written, so that the output parses. Parsed values are always balanced.
+	UnbalancedBraces BraceFormat
+
+	// TabWidth, if positive, replaces each tab character in values with this
+	// many spaces. Indentation is always written with spaces.
+	TabWidth int
+
+	// Header is a banner written as a % comment block before the entries.
+	Header string
+	// Timestamp, if non-zero, is written in the header. It is unset by
+	// default so that output is reproducible.
+	Timestamp time.Time
+}
+
+// Format pretty prints bib to w.
+func (f *Formatter) Format(w io.Writer, bib *BibTex) error {
+	var buf bytes.Buffer
+	f.header(&buf)
+	if f.PreserveMacros {
+		stringVars(&buf, bib)
+	}
+	for i, entry := range bib.Entries {
+		if i != 0 {
+			buf.WriteString(strings.Repeat("\n", f.blankLines(bib, bib.Entries[i-1], entry)))
+		}
+		f.entry(&buf, entry)
+	}
+
+	// Ensure a single trailing newline.
+	out := bytes.TrimRight(buf.Bytes(), "\n")
+	if len(out) > 0 {
+		out = append(out, '\n')
+	}
+	_, err := w.Write(f.lineEndings(out))
+	return err
+}
+
+// entry pretty prints a single entry to buf.
+func (f *Formatter) entry(buf *bytes.Buffer, entry *BibEntry) {
+	for _, comment := range entry.LeadingComments {
+		fmt.Fprintln(buf, strings.TrimSpace("% "+comment))
+	}
+	open, close := f.delimiters(entry)
+	typ := entry.Type
+	if f.TypeAliases && entry.Alias != "" {
+		typ = entry.Alias
+	}
+	if !f.LowercaseTypes && strings.EqualFold(entry.RawType, typ) {
+		typ = entry.RawType
+	}
+	fmt.Fprintf(buf, "@%s%c%s,\n", typ, open, entry.CiteName)
+
+	// Determine key order.
+	keys := []string{}
+	for key, value := range entry.Fields {
+		if f.DropEmpty && strings.TrimSpace(value.String()) == "" {
+			continue
+		}
+		if f.wantField(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	priority := map[string]int{"title": -3, "author": -2, "url": -1}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := priority[keys[i]], priority[keys[j]]
+		return pi < pj || (pi == pj && keys[i] < keys[j])
+	})
+
+	// Write fields.
+	tw := tabwriter.NewWriter(buf, 1, 4, 1, ' ', tabwriter.StripEscape)
+	for _, key := range keys {
+		value, format := f.value(key, entry.Fields[key])
+		// Bracket the value with tabwriter.Escape (\xff) so that tabs in it
+		// are not taken as cell breaks.
+		fmt.Fprintf(tw, "    %s\t=\t\xff"+format+"\xff,\n", key, value)
+	}
+	tw.Flush()
+
+	// Close.
+	fmt.Fprintf(buf, "%c\n", close)
+}
+
+// Encoder writes entries to an output stream one at a time, formatted in the
+// same way as Formatter.Format, so that the whole output is never held in
+// memory. String variable definitions are not written by PreserveMacros.
+type Encoder struct {
+	w    io.Writer
+	f    *Formatter
+	prev *BibEntry // Last entry written.
+	buf  bytes.Buffer
+}
+
+// NewEncoder returns an encoder writing to w with the options of f. A nil f
+// is the zero Formatter.
+func NewEncoder(w io.Writer, f *Formatter) *Encoder {
+	if f == nil {
+		f = &Formatter{}
+	}
+	return &Encoder{w: w, f: f}
+}
+
+// Encode writes entry to the stream, preceded by the header if it is the
+// first entry, or by the blank lines separating it from the previous entry.
+func (e *Encoder) Encode(entry *BibEntry) error {
+	e.buf.Reset()
+	if e.prev == nil {
+		e.f.header(&e.buf)
+	} else {
+		e.buf.WriteString(strings.Repeat("\n", e.f.blankLines(nil, e.prev, entry)))
+	}
+	if e.buf.Len() > 0 {
+		if _, err := e.w.Write(e.f.lineEndings(e.buf.Bytes())); err != nil {
+			return err
+		}
+	}
+	e.prev = entry
+	return WriteEntry(e.w, entry, *e.f)
+}
+
+// WriteEntry pretty prints a single entry to w with the options of f, without
+// any header, string variables or surrounding blank lines.
+func WriteEntry(w io.Writer, entry *BibEntry, f Formatter) error {
+	var buf bytes.Buffer
+	f.entry(&buf, entry)
+	_, err := w.Write(f.lineEndings(buf.Bytes()))
+	return err
+}
+
+// BraceFormat is an output format for unmatched braces in values.
+type BraceFormat int
+
+const (
+	// BracesEscape writes unmatched braces escaped, as \{ and \}.
+	BracesEscape BraceFormat = iota
+	// BracesRemove drops unmatched braces.
+	BracesRemove
+)
+
+// AccentMode is an output format for accented letters in values.
+type AccentMode int
+
+const (
+	// AccentsUnchanged writes accented letters as parsed.
+	AccentsUnchanged AccentMode = iota
+	// AccentsUnicode writes accent commands such as {\'e} as the Unicode
+	// letters they produce. Other LaTeX markup is kept.
+	AccentsUnicode
+	// AccentsLaTeX writes accented and other non-ASCII letters as LaTeX
+	// commands, as by EncodeLaTeX.
+	AccentsLaTeX
+)
+
+// verbatimFields are the fields whose values are not text, and so are not
+// changed by Accents or EscapeAmpersands.
+var verbatimFields = []string{"url", "doi", "file"}
+
+// convertAccents returns value with its accented letters written as mode requires.
+func convertAccents(value string, mode AccentMode) string {
+	switch mode {
+	case AccentsUnicode:
+		return decodeAccents(value)
+	case AccentsLaTeX:
+		return EncodeLaTeX(decodeAccents(value))
+	}
+	return value
+}
+
+// balanceBraces returns s with its unmatched braces escaped or removed. A
+// trailing backslash, which would escape the closing delimiter, is treated in
+// the same way.
+func balanceBraces(s string, format BraceFormat) string {
+	runes := []rune(s)
+	unmatched := map[int]bool{}
+	var open []int
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i == len(runes)-1 {
+				unmatched[i] = true
+			}
+			i++ // Escaped delimiters do not count.
+		case '{':
+			open = append(open, i)
+		case '}':
+			if len(open) == 0 {
+				unmatched[i] = true
+			} else {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	for _, i := range open {
+		unmatched[i] = true
+	}
+	if len(unmatched) == 0 {
+		return s
+	}
+	var buf strings.Builder
+	for i, ch := range runes {
+		if unmatched[i] {
+			if format == BracesRemove {
+				continue
+			}
+			buf.WriteRune('\\')
+		}
+		buf.WriteRune(ch)
+	}
+	return buf.String()
+}
+
+// DelimiterFormat is an output format for the brackets enclosing entries.
+type DelimiterFormat int
+
+const (
+	// DelimitersUnchanged writes each entry with the brackets it was parsed
+	// with.
+	DelimitersUnchanged DelimiterFormat = iota
+	// DelimitersBraces writes all entries as @type{...}.
+	DelimitersBraces
+	// DelimitersParens writes all entries as @type(...).
+	DelimitersParens
+)
+
+// BlankLineFormat is an output format for the spacing between entries.
+type BlankLineFormat int
+
+const (
+	// BlankLinesCompact writes exactly one blank line between entries.
+	BlankLinesCompact BlankLineFormat = iota
+	// BlankLinesNone writes entries with no blank lines between them.
+	BlankLinesNone
+	// BlankLinesPreserve keeps the number of blank lines between entries in
+	// the parsed source. Entries out of source order, or not parsed, are
+	// separated by one blank line.
+	BlankLinesPreserve
+)
+
+// LineEnding is the newline sequence of formatted output.
+type LineEnding int
+
+const (
+	// LineEndingLF ends lines with \n.
+	LineEndingLF LineEnding = iota
+	// LineEndingCRLF ends lines with \r\n, as is usual on Windows.
+	LineEndingCRLF
+)
+
+// lineEndings rewrites the newlines in out, which are all \n bar any \r\n
+// kept in values, to the configured line ending.
+func (f *Formatter) lineEndings(out []byte) []byte {
+	if f.LineEnding != LineEndingCRLF {
+		return out
+	}
+	out = bytes.Replace(out, []byte("\r\n"), []byte("\n"), -1)
+	return bytes.Replace(out, []byte("\n"), []byte("\r\n"), -1)
+}
+
+// blankLines returns the number of blank lines to write between the entries
+// prev and next of bib. If bib is nil, preserved spacing is unknown.
+func (f *Formatter) blankLines(bib *BibTex, prev, next *BibEntry) int {
+	switch f.BlankLines {
+	case BlankLinesNone:
+		return 0
+	case BlankLinesPreserve:
+		if bib != nil && prev.end > 0 && prev.end <= next.start && next.start <= len(bib.source) {
+			return blankLines(bib.source[prev.end:next.start])
+		}
+	}
+	return 1
+}
+
+// blankLines counts the lines in the text between two entries that are empty
+// or only whitespace. The partial lines at either end are not included.
+func blankLines(gap []byte) int {
+	lines := bytes.Split(gap, []byte("\n"))
+	n := 0
+	for i := 1; i < len(lines)-1; i++ {
+		if len(bytes.TrimSpace(lines[i])) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// delimiters returns the opening and closing brackets for entry.
+func (f *Formatter) delimiters(entry *BibEntry) (rune, rune) {
+	if f.Delimiters == DelimitersParens || f.Delimiters == DelimitersUnchanged && entry.Parens {
+		return '(', ')'
+	}
+	return '{', '}'
+}
+
+// stringVars writes the definitions of the string variables in bib, in the
+// order given by StringVarOrder.
+func stringVars(buf *bytes.Buffer, bib *BibTex) {
+	order := bib.StringVarOrder()
+	if len(order) == 0 {
+		return
+	}
+	for _, key := range order {
+		v := bib.StringVar[key]
+		fmt.Fprintf(buf, "@string{%s = %s}\n", v.Key, v.Value.RawString())
+	}
+	buf.WriteString("\n")
+}
+
+// header writes the header comment block, if any.
+func (f *Formatter) header(buf *bytes.Buffer) {
+	var lines []string
+	if f.Header != "" {
+		lines = strings.Split(strings.TrimRight(f.Header, "\n"), "\n")
+	}
+	if !f.Timestamp.IsZero() {
+		lines = append(lines, "Generated "+f.Timestamp.Format(time.RFC3339))
+	}
+	if len(lines) == 0 {
+		return
+	}
+	for _, line := range lines {
+		fmt.Fprintf(buf, "%% %s\n", line)
+	}
+	buf.WriteString("\n")
+}
+
+// value returns the value and formatting verb to use for the given field.
+func (f *Formatter) value(key string, value BibString) (string, string) {
+	if f.PreserveMacros {
+		switch value.(type) {
+		case *BibVar, *BibComposite:
+			return value.RawString(), "%s"
+		}
+	}
+	return f.field(key, value.String())
+}
+
+// field returns the value and formatting verb to use for the given field.
+func (f *Formatter) field(key, value string) (string, string) {
+	if strings.EqualFold(key, "month") && f.MonthFormat != MonthUnchanged {
+		if m, ok := parseMonth(value); ok {
+			switch f.MonthFormat {
+			case MonthMacro:
+				return monthMacros[m-1], "%s"
+			case MonthLong:
+				return m.String(), "{%s}"
+			case MonthNumeric:
+				return fmt.Sprint(int(m)), "%s"
+			}
+		}
+	}
+	if strings.EqualFold(key, "pages") && f.NormalizePages {
+		value = NormalizePages(value)
+	}
+	if (key == "author" || key == "editor") && f.NormalizeNames {
+		value = NormalizeNames(value)
+	}
+	if containsFold(f.TrimTrailingPunctuation, key) {
+		value = TrimTrailingPunctuation(value)
+	}
+	if f.NormalizeWhitespace {
+		if f.preserveWhitespace(key) {
+			value = reindent(value, "        ")
+		} else {
+			value = collapseSpace(value)
+		}
+	}
+	if !containsFold(verbatimFields, key) {
+		value = convertAccents(value, f.Accents)
+		if f.EscapeAmpersands {
+			value = EscapeAmpersands(value)
+		}
+	}
+	if f.TabWidth > 0 {
+		value = strings.Replace(value, "\t", strings.Repeat(" ", f.TabWidth), -1)
+	}
+	value = balanceBraces(value, f.UnbalancedBraces)
+	return value, stringformat(value)
+}
+
+// wantField reports whether the field is written, given IncludeFields and
+// ExcludeFields.
+func (f *Formatter) wantField(key string) bool {
+	if len(f.IncludeFields) > 0 && !containsFold(f.IncludeFields, key) {
+		return false
+	}
+	return !containsFold(f.ExcludeFields, key)
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, t := range list {
+		if strings.EqualFold(t, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultPreserveWhitespace are the fields whose line breaks are kept by
+// default, since they hold prose.
+var defaultPreserveWhitespace = []string{"note", "annote", "abstract"}
+
+// preserveWhitespace reports whether line breaks in the field are kept.
+func (f *Formatter) preserveWhitespace(key string) bool {
+	fields := f.PreserveWhitespace
+	if fields == nil {
+		fields = defaultPreserveWhitespace
+	}
+	return containsFold(fields, key)
+}
+
+// reindent collapses whitespace within each line of a multi-line value, and
+// starts every line after the first with indent. Blank lines are kept empty.
+func reindent(value, indent string) string {
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	for i, line := range lines {
+		line = collapseSpace(line)
+		if i > 0 && line != "" {
+			line = indent + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
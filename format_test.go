@@ -0,0 +1,346 @@
+package bibtex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AssertFormat checks that formatting src with f produces expected.
+func AssertFormat(t *testing.T, f *Formatter, src, expected string) {
+	t.Helper()
+	bib, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, bib); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestFormatMonth(t *testing.T) {
+	src := "@misc{m, month = 7}"
+	cases := []struct {
+		Format   MonthFormat
+		Expected string
+	}{
+		{MonthUnchanged, "@misc{m,\n    month = 7,\n}\n"},
+		{MonthMacro, "@misc{m,\n    month = jul,\n}\n"},
+		{MonthLong, "@misc{m,\n    month = {July},\n}\n"},
+		{MonthNumeric, "@misc{m,\n    month = 7,\n}\n"},
+	}
+	for _, c := range cases {
+		AssertFormat(t, &Formatter{MonthFormat: c.Format}, src, c.Expected)
+	}
+}
+
+func TestFormatMonthFieldCase(t *testing.T) {
+	AssertFormat(t, &Formatter{MonthFormat: MonthNumeric}, "@misc{m, Month = jul}", "@misc{m,\n    Month = 7,\n}\n")
+}
+
+func TestFormatMonthUnparseable(t *testing.T) {
+	src := "@misc{m, month = {Summer}}"
+	AssertFormat(t, &Formatter{MonthFormat: MonthNumeric}, src, "@misc{m,\n    month = \"Summer\",\n}\n")
+}
+
+func TestMonth(t *testing.T) {
+	for _, value := range []string{"7", "07", "jul", "Jul.", "July"} {
+		entry := NewBibEntry("misc", "m")
+		entry.AddField("month", NewBibConst(value))
+		if m, ok := entry.Month(); !ok || m != 7 {
+			t.Errorf("month %q: got %v, %v", value, m, ok)
+		}
+	}
+	entry := MustParse(t, "@misc{m, Month = mar}").Entries[0]
+	if m, ok := entry.Month(); !ok || m != 3 {
+		t.Errorf("Month field: got %v, %v", m, ok)
+	}
+}
+
+// Tests that month macros are predefined and round trip through the formatter.
+func TestMonthMacro(t *testing.T) {
+	AssertFormat(t, &Formatter{MonthFormat: MonthMacro}, "@misc{m, month = jul}", "@misc{m,\n    month = jul,\n}\n")
+}
+
+func TestFormatHeader(t *testing.T) {
+	f := &Formatter{Header: "Generated file.\nDo not edit.\n"}
+	src := "@misc{m, title = {T}}\n\n\n"
+	expect := "% Generated file.\n% Do not edit.\n\n@misc{m,\n    title = \"T\",\n}\n"
+	AssertFormat(t, f, src, expect)
+
+	// Output parses back to the same entries.
+	bib := MustParse(t, expect)
+	AssertEntryListsEqual(t, MustParse(t, src).Entries, bib.Entries)
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	f := &Formatter{Header: "Banner", Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	expect := "% Banner\n% Generated 2020-01-02T03:04:05Z\n\n@misc{m,\n    title = \"T\",\n}\n"
+	AssertFormat(t, f, "@misc{m, title = {T}}", expect)
+}
+
+func TestFormatDelimiters(t *testing.T) {
+	src := "@article{a,\n    title = \"A\",\n}\n\n@book(b,\n    title = \"B\",\n)\n"
+	AssertFormat(t, &Formatter{}, src, src)
+
+	braces := "@article{a,\n    title = \"A\",\n}\n\n@book{b,\n    title = \"B\",\n}\n"
+	AssertFormat(t, &Formatter{Delimiters: DelimitersBraces}, src, braces)
+}
+
+// Tests that the other entry kinds accept parentheses.
+func TestParenEntries(t *testing.T) {
+	bib := MustParse(t, `@string(x = {X}) @preamble("\newcommand{\foo}{}") @comment("c") @misc(m, t = x)`)
+	if got := bib.Entries[0].Fields["t"].String(); got != "X" {
+		t.Errorf("got %q", got)
+	}
+	if len(bib.Preambles) != 1 {
+		t.Errorf("expected preamble")
+	}
+}
+
+func TestFormatTabWidth(t *testing.T) {
+	src := "@misc{a, note = \"one\ttwo\"}"
+	AssertFormat(t, &Formatter{TabWidth: 2}, src, "@misc{a,\n    note = \"one  two\",\n}\n")
+	AssertFormat(t, &Formatter{}, src, "@misc{a,\n    note = \"one\ttwo\",\n}\n")
+}
+
+func TestFormatLeadingComments(t *testing.T) {
+	bib := MustParse(t, `% Bibliography of things.
+
+@misc{b, title = {B}}
+
+% note
+% more
+@article{a, title = {A}}
+`)
+	if c := bib.Entries[0].LeadingComments; len(c) != 0 {
+		t.Errorf("free-floating comment attached to entry: %q", c)
+	}
+	if c := bib.Entries[1].LeadingComments; len(c) != 2 || c[0] != "note" || c[1] != "more" {
+		t.Errorf("got leading comments %q", c)
+	}
+
+	bib.Entries[0], bib.Entries[1] = bib.Entries[1], bib.Entries[0]
+	var buf bytes.Buffer
+	if err := (&Formatter{}).Format(&buf, bib); err != nil {
+		t.Fatal(err)
+	}
+	expect := "% note\n% more\n@article{a,\n    title = \"A\",\n}\n\n@misc{b,\n    title = \"B\",\n}\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("got\n%s\nexpected\n%s", got, expect)
+	}
+}
+
+func TestFormatNormalizeNames(t *testing.T) {
+	src := "@misc{a, author = {Doe, J.and  Roe, R.}}"
+	AssertFormat(t, &Formatter{NormalizeNames: true}, src, "@misc{a,\n    author = \"Doe, J. and Roe, R.\",\n}\n")
+}
+
+func TestFormatNormalizeWhitespace(t *testing.T) {
+	f := &Formatter{NormalizeWhitespace: true}
+	src := "@misc{a,\n  note = {First   line\n  second line\n\n     new paragraph},\n  title = {A\n   Title},\n}\n"
+	expect := "@misc{a,\n    title = \"A Title\",\n    note  = \"First line\n        second line\n\n        new paragraph\",\n}\n"
+	AssertFormat(t, f, src, expect)
+	AssertFormat(t, f, expect, expect)
+
+	bib := MustParse(t, expect)
+	if got := bib.Entries[0].Fields["note"].String(); strings.Count(got, "\n") != 3 {
+		t.Errorf("line breaks not preserved in note %q", got)
+	}
+}
+
+func TestFormatPreserveMacros(t *testing.T) {
+	src := "@string{ieee = {IEEE}}\n@article{a,\n    journal = ieee # \" Trans.\",\n    month   = jul,\n    title   = {T},\n}\n"
+	expect := "@string{ieee = {IEEE}}\n\n@article{a,\n    title   = \"T\",\n    journal = ieee # \" Trans.\",\n    month   = jul,\n}\n"
+	AssertFormat(t, &Formatter{PreserveMacros: true}, src, expect)
+	AssertFormat(t, &Formatter{PreserveMacros: true}, expect, expect)
+
+	resolved := "@article{a,\n    title   = \"T\",\n    journal = \"IEEE Trans.\",\n    month   = \"July\",\n}\n"
+	AssertFormat(t, &Formatter{}, src, resolved)
+}
+
+func TestFormatBlankLines(t *testing.T) {
+	b, err := ioutil.ReadFile("example/spacing.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(b)
+	compact := "@misc{a,\n    title = \"A\",\n}\n\n@misc{b,\n    title = \"B\",\n}\n\n@misc{c,\n    title = \"C\",\n}\n\n% about d\n@misc{d,\n    title = \"D\",\n}\n"
+	AssertFormat(t, &Formatter{}, src, compact)
+
+	none := strings.Replace(compact, "}\n\n", "}\n", -1)
+	AssertFormat(t, &Formatter{BlankLines: BlankLinesNone}, src, none)
+
+	preserve := "@misc{a,\n    title = \"A\",\n}\n@misc{b,\n    title = \"B\",\n}\n\n\n\n@misc{c,\n    title = \"C\",\n}\n\n\n% about d\n@misc{d,\n    title = \"D\",\n}\n"
+	AssertFormat(t, &Formatter{BlankLines: BlankLinesPreserve}, src, preserve)
+}
+
+func TestFormatFieldProjection(t *testing.T) {
+	src := "@article{a, title = {T}, Abstract = {Long text}, year = 2020, file = {a.pdf}}"
+	AssertFormat(t, &Formatter{ExcludeFields: []string{"abstract", "FILE"}}, src, "@article{a,\n    title = \"T\",\n    year  = 2020,\n}\n")
+	AssertFormat(t, &Formatter{IncludeFields: []string{"title"}}, src, "@article{a,\n    title = \"T\",\n}\n")
+
+	bib := MustParse(t, src)
+	if err := (&Formatter{ExcludeFields: []string{"abstract"}}).Format(ioutil.Discard, bib); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bib.Entries[0].Fields["Abstract"]; !ok {
+		t.Errorf("projection modified the entry")
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	bib, err := ParseFile("example/biblatex-examples.bib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*Formatter{{}, {Header: "Examples", BlankLines: BlankLinesNone}} {
+		var batch, stream bytes.Buffer
+		if err := f.Format(&batch, bib); err != nil {
+			t.Fatal(err)
+		}
+		enc := NewEncoder(&stream, f)
+		for _, entry := range bib.Entries {
+			if err := enc.Encode(entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if stream.String() != batch.String() {
+			t.Errorf("encoder output differs from Format with %+v", f)
+		}
+	}
+}
+
+func TestFormatDropEmpty(t *testing.T) {
+	src := "@article{a, title = {T}, abstract = { }, note = {}}"
+	AssertFormat(t, &Formatter{DropEmpty: true}, src, "@article{a,\n    title = \"T\",\n}\n")
+	AssertFormat(t, &Formatter{}, src, "@article{a,\n    title    = \"T\",\n    abstract = \" \",\n    note     = \"\",\n}\n")
+}
+
+func TestFormatLineEnding(t *testing.T) {
+	src := "% Leading\n@misc{a,\n  note = {First line\n  second line},\n  title = {T},\n}\n@misc{b, title = {U}}\n"
+	lf := "% Leading\n@misc{a,\n    title = \"T\",\n    note  = \"First line\n        second line\",\n}\n\n@misc{b,\n    title = \"U\",\n}\n"
+	crlf := strings.Replace(lf, "\n", "\r\n", -1)
+	f := &Formatter{NormalizeWhitespace: true}
+	AssertFormat(t, f, src, lf)
+	f.LineEnding = LineEndingCRLF
+	AssertFormat(t, f, src, crlf)
+	AssertFormat(t, f, crlf, crlf)
+}
+
+func TestWriteEntry(t *testing.T) {
+	bib := MustParse(t, "% Note\n@article{a, title = {T}, month = {July}, pages = {1-10}}")
+	cases := []struct {
+		Formatter Formatter
+		Expected  string
+	}{
+		{Formatter{}, "% Note\n@article{a,\n    title = \"T\",\n    month = \"July\",\n    pages = \"1-10\",\n}\n"},
+		{Formatter{MonthFormat: MonthMacro, NormalizePages: true, Delimiters: DelimitersParens}, "% Note\n@article(a,\n    title = \"T\",\n    month = jul,\n    pages = \"1--10\",\n)\n"},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := WriteEntry(&buf, bib.Entries[0], c.Formatter); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != c.Expected {
+			t.Errorf("got\n%s\nexpected\n%s", got, c.Expected)
+		}
+	}
+}
+
+func TestFormatTypeCase(t *testing.T) {
+	src := "@InProceedings{a, title = {T}}"
+	AssertFormat(t, &Formatter{}, src, "@InProceedings{a,\n    title = \"T\",\n}\n")
+	AssertFormat(t, &Formatter{LowercaseTypes: true}, src, "@inproceedings{a,\n    title = \"T\",\n}\n")
+	AssertFormat(t, &Formatter{TypeAliases: true}, "@WWW{a, title = {T}}", "@WWW{a,\n    title = \"T\",\n}\n")
+	AssertFormat(t, &Formatter{}, "@WWW{a, title = {T}}", "@online{a,\n    title = \"T\",\n}\n")
+
+	entry := MustParse(t, src).Entries[0]
+	if entry.Type != "inproceedings" || entry.Kind() != EntryInProceedings {
+		t.Errorf("got type %q", entry.Type)
+	}
+	entry.Type = "article"
+	var buf bytes.Buffer
+	if err := WriteEntry(&buf, entry, Formatter{}); err != nil || !strings.HasPrefix(buf.String(), "@article{") {
+		t.Errorf("changed type not written: %q", buf.String())
+	}
+}
+
+func TestFormatBracesRoundTrip(t *testing.T) {
+	bib := MustParse(t, `@misc{a,
+  open = {a \{ b},
+  close = {a \} b},
+  both = {\{x\}},
+  nested = {nested {braces {deep}}},
+  quoted = "a \{ b",
+  quotes = {x "q" y},
+  slash = {ends with \\},
+}`)
+	var buf bytes.Buffer
+	if err := (&Formatter{}).Format(&buf, bib); err != nil {
+		t.Fatal(err)
+	}
+	again := MustParse(t, buf.String())
+	for key, value := range bib.Entries[0].Fields {
+		if got := again.Entries[0].Fields[key].String(); got != value.String() {
+			t.Errorf("%s: got %q after round trip, expected %q", key, got, value.String())
+		}
+	}
+}
+
+func TestFormatUnbalancedBraces(t *testing.T) {
+	entry := NewBibEntry("misc", "a")
+	entry.AddField("open", NewBibConst("a { b"))
+	entry.AddField("close", NewBibConst("a } b {c}"))
+	entry.AddField("slash", NewBibConst(`end\`))
+	cases := []struct {
+		Format BraceFormat
+		Expect map[string]string
+	}{
+		{BracesEscape, map[string]string{"open": `a \{ b`, "close": `a \} b {c}`, "slash": `end\\`}},
+		{BracesRemove, map[string]string{"open": "a  b", "close": "a  b {c}", "slash": "end"}},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := WriteEntry(&buf, entry, Formatter{UnbalancedBraces: c.Format}); err != nil {
+			t.Fatal(err)
+		}
+		AssertFields(t, MustParse(t, buf.String()).Entries[0], c.Expect)
+	}
+}
+
+func TestFormatKeySpacing(t *testing.T) {
+	expect := "@book{key,\n    title = \"T\",\n}\n"
+	for _, src := range []string{"@book{ key , title={T}}", "@book{\n  key\n  ,\n  title={T}\n}", "@book( key ,title={T} )"} {
+		AssertFormat(t, &Formatter{Delimiters: DelimitersBraces}, src, expect)
+	}
+}
+
+func TestFormatAccents(t *testing.T) {
+	src := `@misc{a, author = {M{\"u}ller, J. and Gödel, K.}, title = {\'Etude {DNA}}, url = {http://example.com/é}}`
+	AssertFormat(t, &Formatter{Accents: AccentsUnicode}, src, "@misc{a,\n"+
+		"    title  = {Étude {DNA}},\n"+
+		"    author = \"Müller, J. and Gödel, K.\",\n"+
+		"    url    = \"http://example.com/é\",\n"+
+		"}\n")
+	AssertFormat(t, &Formatter{Accents: AccentsLaTeX}, src, "@misc{a,\n"+
+		"    title  = {{\\'E}tude {DNA}},\n"+
+		"    author = {M{\\\"u}ller, J. and G{\\\"o}del, K.},\n"+
+		"    url    = \"http://example.com/é\",\n"+
+		"}\n")
+}
+
+func TestFormatTrimTrailingPunctuation(t *testing.T) {
+	src := `@article{a, title = {A Study.}, journal = {Acme Inc.}, note = {Ends.}}`
+	AssertFormat(t, &Formatter{TrimTrailingPunctuation: []string{"title", "Journal"}}, src, "@article{a,\n"+
+		"    title   = \"A Study\",\n"+
+		"    journal = \"Acme Inc.\",\n"+
+		"    note    = \"Ends.\",\n"+
+		"}\n")
+}
@@ -1,5 +1,5 @@
 module github.com/mmcloughlin/bibtex
 
-go 1.11
+go 1.16
 
 require github.com/BurntSushi/toml v0.3.1
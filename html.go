@@ -0,0 +1,88 @@
+package bibtex
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// HTMLOptions configures HTML output.
+type HTMLOptions struct {
+	// Class, if set, is the class attribute of the list element.
+	Class string
+	// Template, if set, renders each list item in place of the default
+	// markup. It is executed with a *Citation.
+	Template *template.Template
+}
+
+// ToHTML writes the bibliography as an HTML list, with one <li> per entry.
+func (bib *BibTex) ToHTML(w io.Writer, opts HTMLOptions) error {
+	var buf bytes.Buffer
+	if opts.Class != "" {
+		fmt.Fprintf(&buf, "<ul class=\"%s\">\n", html.EscapeString(opts.Class))
+	} else {
+		buf.WriteString("<ul>\n")
+	}
+	for _, entry := range bib.Entries {
+		c := NewCitation(entry)
+		buf.WriteString("<li>")
+		if opts.Template != nil {
+			if err := opts.Template.Execute(&buf, c); err != nil {
+				return err
+			}
+		} else {
+			writeHTMLCitation(&buf, c)
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeHTMLCitation writes the default markup for a citation.
+func writeHTMLCitation(buf *bytes.Buffer, c *Citation) {
+	var parts []string
+	if c.Authors != "" {
+		parts = append(parts, html.EscapeString(sentence(c.Authors)))
+	}
+	if c.Title != "" {
+		parts = append(parts, "<em>"+html.EscapeString(c.Title)+"</em>"+sentence(c.Title)[len(c.Title):])
+	}
+	switch {
+	case c.Venue != "" && c.Year != "":
+		parts = append(parts, html.EscapeString(sentence(c.Venue+", "+c.Year)))
+	case c.Venue != "":
+		parts = append(parts, html.EscapeString(sentence(c.Venue)))
+	case c.Year != "":
+		parts = append(parts, html.EscapeString(sentence(c.Year)))
+	}
+	if safeURL(c.URL) {
+		text := c.URL
+		if c.DOI != "" {
+			text = "doi:" + c.DOI
+		}
+		parts = append(parts, fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(c.URL), html.EscapeString(text)))
+	}
+	for i, part := range parts {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(part)
+	}
+}
+
+// safeURL reports whether s is an absolute http or https URL, and so is safe
+// to link to. Other schemes, such as javascript:, are not linked.
+func safeURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && u.Host != ""
+}
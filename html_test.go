@@ -0,0 +1,65 @@
+package bibtex
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	bib := MustParse(t, `@article{a,
+  author = {M{\"u}ller, J. and Smith, A.},
+  title = {Fish \& Chips: \textit{A} <Study>},
+  journal = {J. Food},
+  year = 2020,
+  doi = {10.1000/182},
+}`)
+	var buf bytes.Buffer
+	if err := bib.ToHTML(&buf, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "<ul>\n<li>Müller, J., Smith, A. <em>Fish &amp; Chips: A &lt;Study&gt;</em>. J. Food, 2020. <a href=\"https://doi.org/10.1000/182\">doi:10.1000/182</a></li>\n</ul>\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("got\n%s\nexpected\n%s", got, expect)
+	}
+}
+
+func TestToHTMLUnsafeURL(t *testing.T) {
+	bib := MustParse(t, `@misc{a, title = {T}, url = {javascript:alert(1)}}`)
+	var buf bytes.Buffer
+	if err := bib.ToHTML(&buf, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, "<a") || strings.Contains(got, "javascript") {
+		t.Errorf("unsafe url linked: %s", got)
+	}
+}
+
+func TestCitationAuthors(t *testing.T) {
+	cases := map[string]string{
+		"Doe, J. and Roe, R.":        "Doe, J., Roe, R.",
+		"Doe, J. AND Roe, R.":        "Doe, J., Roe, R.",
+		"J.and R. Roe":               "J., R. Roe",
+		"{Barnes and Noble} and Doe": "Barnes and Noble, Doe",
+	}
+	for authors, expect := range cases {
+		entry := MustParse(t, "@misc{a, author = {"+authors+"}}").Entries[0]
+		if got := NewCitation(entry).Authors; got != expect {
+			t.Errorf("%q: got authors %q, expected %q", authors, got, expect)
+		}
+	}
+}
+
+func TestToHTMLTemplate(t *testing.T) {
+	bib := MustParse(t, `@misc{a, title = {A & B}, url = {http://example.com/?a=1&b=2}}`)
+	tmpl := template.Must(template.New("").Parse(`<a href="{{.URL}}">{{.Title}}</a>`))
+	var buf bytes.Buffer
+	if err := bib.ToHTML(&buf, HTMLOptions{Class: "refs", Template: tmpl}); err != nil {
+		t.Fatal(err)
+	}
+	expect := `<a href="http://example.com/?a=1&amp;b=2">A &amp; B</a>`
+	if got := buf.String(); !strings.HasPrefix(got, `<ul class="refs">`) || !strings.Contains(got, expect) {
+		t.Errorf("unexpected output %s", got)
+	}
+}
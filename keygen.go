@@ -0,0 +1,164 @@
+package bibtex
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// keyMarkerRe matches a field marker in a key pattern, such as [auth] or
+// [title:lower].
+var keyMarkerRe = regexp.MustCompile(`\[([a-z]+)(?::([a-z]+))?\]`)
+
+// functionWords are ignored when abbreviating titles.
+var functionWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "but": true, "nor": true,
+	"or": true, "so": true, "yet": true, "about": true, "above": true,
+	"across": true, "against": true, "along": true, "among": true,
+	"around": true, "at": true, "before": true, "behind": true, "below": true,
+	"beneath": true, "beside": true, "between": true, "beyond": true,
+	"by": true, "down": true, "during": true, "except": true, "for": true,
+	"from": true, "in": true, "inside": true, "into": true, "like": true,
+	"near": true, "of": true, "off": true, "on": true, "onto": true,
+	"since": true, "to": true, "toward": true, "through": true, "under": true,
+	"until": true, "up": true, "upon": true, "with": true, "within": true,
+	"without": true,
+}
+
+// GenerateKey generates a citation key for the entry from a pattern, in the
+// style of JabRef. Text outside brackets is copied, and the markers are
+// replaced as follows:
+//
+//	[auth]           last name of the first author, or editor
+//	[authors]        last names of all authors
+//	[year]           year
+//	[title]          significant words of the title, capitalized
+//	[shorttitle]     first three significant words of the title
+//	[veryshorttitle] first significant word of the title
+//
+// A marker may be followed by :lower or :upper to change its case, as in
+// [auth:lower]. Accents and punctuation are removed, and unknown markers are
+// dropped, so that the result is a valid key.
+//
+// If the pattern uses an author marker and the entry has no authors or
+// editors, or the pattern yields nothing at all, the key falls back to the
+// first significant title word and year, then the last segment of the DOI,
+// and finally to nokey. BibTex.GenerateKeys numbers the nokey keys.
+func (entry *BibEntry) GenerateKey(pattern string) string {
+	if key, ok := entry.expandKey(pattern); ok {
+		return SanitizeKey(key)
+	}
+	if key, _ := entry.expandKey("[veryshorttitle][year]"); key != "" {
+		return SanitizeKey(key)
+	}
+	if doi := fieldString(entry, "doi"); doi != "" {
+		doi = strings.TrimRight(doi, "/")
+		return SanitizeKey(doi[strings.LastIndex(doi, "/")+1:])
+	}
+	return noKey
+}
+
+// noKey is the key generated for an entry with nothing usable in it.
+const noKey = "nokey"
+
+// expandKey replaces the markers in pattern with their values for the entry.
+// It returns false if the result is empty or an author marker is.
+func (entry *BibEntry) expandKey(pattern string) (string, bool) {
+	ok := true
+	key := keyMarkerRe.ReplaceAllStringFunc(pattern, func(marker string) string {
+		m := keyMarkerRe.FindStringSubmatch(marker)
+		value := entry.keyMarker(m[1])
+		if value == "" && (m[1] == "auth" || m[1] == "authors") {
+			ok = false
+		}
+		switch m[2] {
+		case "lower":
+			value = strings.ToLower(value)
+		case "upper":
+			value = strings.ToUpper(value)
+		}
+		return value
+	})
+	return key, ok && strings.IndexFunc(key, func(ch rune) bool { return !isUnsafeKeyChar(ch) }) >= 0
+}
+
+// keyMarker returns the value of a key pattern marker for the entry.
+func (entry *BibEntry) keyMarker(name string) string {
+	switch name {
+	case "auth", "authors":
+		names := entry.Names("author")
+		if len(names) == 0 {
+			names = entry.Names("editor")
+		}
+		if name == "auth" && len(names) > 1 {
+			names = names[:1]
+		}
+		var buf strings.Builder
+		for _, n := range names {
+			buf.WriteString(strings.Join(keyWords(n.Last), ""))
+		}
+		return buf.String()
+	case "year":
+		return strings.Join(keyWords(fieldString(entry, "year")), "")
+	case "title", "shorttitle", "veryshorttitle":
+		var words []string
+		for _, word := range keyWords(fieldString(entry, "title")) {
+			if !functionWords[strings.ToLower(word)] {
+				words = append(words, capitalize(word))
+			}
+		}
+		if n := map[string]int{"shorttitle": 3, "veryshorttitle": 1}[name]; n > 0 && len(words) > n {
+			words = words[:n]
+		}
+		return strings.Join(words, "")
+	}
+	return ""
+}
+
+// keyWords splits s into words of ASCII letters and digits, after decoding
+// LaTeX and removing accents.
+func keyWords(s string) []string {
+	return strings.FieldsFunc(ASCIIFold(DecodeLaTeX(s)), func(ch rune) bool {
+		return ch > unicode.MaxASCII || !unicode.IsLetter(ch) && !unicode.IsDigit(ch)
+	})
+}
+
+// capitalize returns word with its first letter in upper case.
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// GenerateKeys generates a key for every entry from pattern, as GenerateKey
+// does, returning them in entry order. Entries with nothing usable are given
+// the keys nokey-1, nokey-2 and so on.
+func (bib *BibTex) GenerateKeys(pattern string) []string {
+	keys := make([]string, len(bib.Entries))
+	n := 0
+	for i, entry := range bib.Entries {
+		keys[i] = entry.GenerateKey(pattern)
+		if keys[i] == noKey {
+			n++
+			keys[i] = noKey + "-" + strconv.Itoa(n)
+		}
+	}
+	return keys
+}
+
+// KeyCollisions generates a key for every entry from pattern, as GenerateKeys
+// does, and returns the groups of entries that would share a key, by key.
+func (bib *BibTex) KeyCollisions(pattern string) map[string][]*BibEntry {
+	groups := map[string][]*BibEntry{}
+	for i, key := range bib.GenerateKeys(pattern) {
+		groups[key] = append(groups[key], bib.Entries[i])
+	}
+	for key, entries := range groups {
+		if len(entries) < 2 {
+			delete(groups, key)
+		}
+	}
+	return groups
+}
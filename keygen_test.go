@@ -0,0 +1,58 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	entry := MustParse(t, `@article{x, author = {M{\"u}ller, Hans and von Neumann, John}, year = 1950, title = {On the Theory of Games and Economic Behavior}}`).Entries[0]
+	cases := map[string]string{
+		"[auth][year]":                 "Muller1950",
+		"[auth:lower][year]":           "muller1950",
+		"[authors]-[year]":             "MullerNeumann-1950",
+		"[auth][year][veryshorttitle]": "Muller1950Theory",
+		"[auth:upper]:[shorttitle]":    "MULLER:TheoryGamesEconomic",
+		"[title]":                      "TheoryGamesEconomicBehavior",
+		"[auth][unknown][year]":        "Muller1950",
+	}
+	for pattern, expect := range cases {
+		if got := entry.GenerateKey(pattern); got != expect {
+			t.Errorf("GenerateKey(%q) = %q; expected %q", pattern, got, expect)
+		}
+	}
+}
+
+func TestKeyCollisions(t *testing.T) {
+	bib := MustParse(t, `
+@article{a, author = {Doe, Jane}, year = 2020, title = {Parsing Bibliographies}}
+@article{b, author = {Doe, John}, year = 2020, title = {A Study of Formatting}}
+@article{c, author = {Roe, Richard}, year = 2020, title = {Parsing}}
+`)
+	collisions := bib.KeyCollisions("[auth][year]")
+	if len(collisions) != 1 || len(collisions["Doe2020"]) != 2 {
+		t.Errorf("got collisions %v, expected Doe2020", collisions)
+	}
+	if collisions := bib.KeyCollisions("[auth][year][veryshorttitle]"); len(collisions) != 0 {
+		t.Errorf("got collisions %v, expected none", collisions)
+	}
+}
+
+func TestGenerateKeyFallback(t *testing.T) {
+	bib := MustParse(t, `
+@misc{a, title = {The Art of Programming}, year = 1968}
+@misc{b, doi = {10.1145/3368089.3409711}}
+@misc{c, doi = {https://doi.org/10.1016/S0140-6736(20)30183-5}}
+@misc{d, note = {Nothing usable}}
+@misc{e, author = {Smith, Jane}, year = 2020}
+@misc{f,}
+`)
+	got := strings.Join(bib.GenerateKeys("[auth][year]"), ",")
+	expect := "Art1968,3368089.3409711,S0140-6736_20_30183-5,nokey-1,Smith2020,nokey-2"
+	if got != expect {
+		t.Errorf("got keys %s, expected %s", got, expect)
+	}
+	if key := bib.Entries[3].GenerateKey("[title]"); key != "nokey" {
+		t.Errorf("got key %q, expected nokey", key)
+	}
+}
@@ -0,0 +1,13 @@
+package bibtex
+
+// Keywords returns the keywords of the entry. Keywords may be separated by
+// commas, semicolons or line breaks, outside braces.
+func (entry *BibEntry) Keywords() []string {
+	var keywords []string
+	for _, k := range splitDepth0(fieldString(entry, "keywords"), ',', ';', '\n') {
+		if k = collapseSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
@@ -0,0 +1,23 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	cases := []struct {
+		Src    string
+		Expect string
+	}{
+		{`@misc{a, keywords = {parsing, bibtex; {Go, the language}}}`, "parsing|bibtex|{Go, the language}"},
+		{"@misc{a, keywords = {\n  parsing\n  bibliography   management,\n  bibtex\n}}", "parsing|bibliography management|bibtex"},
+		{`@misc{a, title = {T}}`, ""},
+	}
+	for _, c := range cases {
+		entry := MustParse(t, c.Src).Entries[0]
+		if got := strings.Join(entry.Keywords(), "|"); got != c.Expect {
+			t.Errorf("%s: got keywords %q, expected %q", c.Src, got, c.Expect)
+		}
+	}
+}
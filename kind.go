@@ -0,0 +1,63 @@
+package bibtex
+
+// EntryKind identifies the standard entry types, with aliases resolved.
+type EntryKind int
+
+const (
+	// EntryUnknown is the kind of entry types that are not standard.
+	EntryUnknown EntryKind = iota
+	EntryArticle
+	EntryBook
+	EntryBooklet
+	EntryInBook
+	EntryInCollection
+	EntryInProceedings
+	EntryManual
+	EntryMastersThesis
+	EntryMisc
+	// EntryOnline is the kind of @online entries, also written as @electronic
+	// or @www.
+	EntryOnline
+	EntryPhDThesis
+	EntryProceedings
+	EntryTechReport
+	EntryUnpublished
+)
+
+// entryKinds maps entry types to their kind.
+var entryKinds = map[string]EntryKind{
+	"article":       EntryArticle,
+	"book":          EntryBook,
+	"booklet":       EntryBooklet,
+	"inbook":        EntryInBook,
+	"incollection":  EntryInCollection,
+	"inproceedings": EntryInProceedings,
+	"manual":        EntryManual,
+	"mastersthesis": EntryMastersThesis,
+	"misc":          EntryMisc,
+	"online":        EntryOnline,
+	"phdthesis":     EntryPhDThesis,
+	"proceedings":   EntryProceedings,
+	"techreport":    EntryTechReport,
+	"unpublished":   EntryUnpublished,
+}
+
+// typeAliases maps alternative entry type names to the canonical type. The
+// parser normalises aliases, recording the original in BibEntry.Alias.
+var typeAliases = map[string]string{
+	"electronic": "online",
+	"www":        "online",
+}
+
+// canonicalType returns the canonical type for the lowercase type t.
+func canonicalType(t string) string {
+	if canonical, ok := typeAliases[t]; ok {
+		return canonical
+	}
+	return t
+}
+
+// Kind returns the kind of the entry, resolving type aliases.
+func (entry *BibEntry) Kind() EntryKind {
+	return entryKinds[canonicalType(entry.Type)]
+}
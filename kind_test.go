@@ -0,0 +1,49 @@
+package bibtex
+
+import "testing"
+
+func TestOnlineAliases(t *testing.T) {
+	bib := MustParse(t, `
+@online{a, url = {http://example.com/a}}
+@Electronic{b, url = {http://example.com/b}}
+@www{c, url = {http://example.com/c}}
+`)
+	for i, alias := range []string{"", "electronic", "www"} {
+		entry := bib.Entries[i]
+		if entry.Type != "online" || entry.Alias != alias || entry.Kind() != EntryOnline {
+			t.Errorf("%s: got type %q, alias %q, kind %d", entry.CiteName, entry.Type, entry.Alias, entry.Kind())
+		}
+	}
+
+	AssertFormat(t, &Formatter{}, "@www{c, title = {T}}", "@online{c,\n    title = \"T\",\n}\n")
+	AssertFormat(t, &Formatter{TypeAliases: true}, "@www{c, title = {T}}", "@www{c,\n    title = \"T\",\n}\n")
+
+	p := &Parser{Types: []string{"online"}}
+	if bib, err := p.ParseBytes([]byte(`@www{c, title = {T}} @misc{d, title = {U}}`)); err != nil || len(bib.Entries) != 1 {
+		t.Errorf("aliases not matched by type filter: %v", err)
+	}
+}
+
+func TestKind(t *testing.T) {
+	cases := map[string]EntryKind{
+		"article":    EntryArticle,
+		"phdthesis":  EntryPhDThesis,
+		"www":        EntryOnline,
+		"dataset":    EntryUnknown,
+		"electronic": EntryOnline,
+	}
+	for typ, kind := range cases {
+		if got := NewBibEntry(typ, "a").Kind(); got != kind {
+			t.Errorf("%s: got kind %d, expected %d", typ, got, kind)
+		}
+	}
+}
+
+func TestUnknownTypeRoundTrip(t *testing.T) {
+	src := "@Dataset{d, title = {Data}}"
+	entry := MustParse(t, src).Entries[0]
+	if entry.Type != "dataset" || entry.RawType != "Dataset" || entry.Kind() != EntryUnknown {
+		t.Errorf("got type %q, raw type %q, kind %d", entry.Type, entry.RawType, entry.Kind())
+	}
+	AssertFormat(t, &Formatter{}, src, "@Dataset{d,\n    title = \"Data\",\n}\n")
+}
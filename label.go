@@ -0,0 +1,71 @@
+package bibtex
+
+import "strings"
+
+// AlphaLabel returns the citation label of the entry in the alpha style, as
+// computed by alpha.bst: the first three letters of a single author's last
+// name, or the initials of up to four authors' last names, followed by the
+// last two digits of the year. Lowercase von parts contribute their initials,
+// and a list of more than four authors, or one ending in "and others", is
+// abbreviated with a +. Editors are used if there is no author, and failing
+// that the key field or the citation key.
+func (entry *BibEntry) AlphaLabel() string {
+	names := entry.Names("author")
+	if len(names) == 0 {
+		names = entry.Names("editor")
+	}
+	var label string
+	switch {
+	case len(names) == 1 && names[0].Last != "others":
+		label = alphaPrefix(vonInitials(names[0])+strings.Join(keyWords(names[0].Last), ""), 3)
+	case len(names) > 0:
+		more := false
+		if len(names) > 4 {
+			names, more = names[:3], true
+		}
+		if last := len(names) - 1; names[last].Last == "others" {
+			names, more = names[:last], true
+		}
+		var buf strings.Builder
+		for _, n := range names {
+			buf.WriteString(vonInitials(n))
+			buf.WriteString(alphaPrefix(strings.Join(keyWords(n.Last), ""), 1))
+		}
+		if more {
+			buf.WriteString("+")
+		}
+		label = buf.String()
+	default:
+		key := strings.Join(keyWords(fieldString(entry, "key")), "")
+		if key == "" {
+			key = strings.Join(keyWords(entry.CiteName), "")
+		}
+		label = alphaPrefix(key, 3)
+	}
+
+	year := strings.Join(keyWords(fieldString(entry, "year")), "")
+	if len(year) > 2 {
+		year = year[len(year)-2:]
+	}
+	return label + year
+}
+
+// vonInitials returns the first letters of the von part of a name.
+func vonInitials(n Name) string {
+	var buf strings.Builder
+	for _, word := range keyWords(n.Von) {
+		buf.WriteString(alphaPrefix(word, 1))
+	}
+	return buf.String()
+}
+
+// alphaPrefix returns the first n characters of s.
+func alphaPrefix(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
@@ -0,0 +1,28 @@
+package bibtex
+
+import "testing"
+
+func TestAlphaLabel(t *testing.T) {
+	cases := []struct {
+		Source string
+		Label  string
+	}{
+		{`@book{a, author = {Knuth, Donald E.}, year = 1984}`, "Knu84"},
+		{`@book{a, author = {Ludwig van Beethoven}, year = 2021}`, "vBe21"},
+		{`@book{a, author = {Aho, A. and Sethi, R. and Ullman, J.}, year = 1986}`, "ASU86"},
+		{`@book{a, author = {Aho, A. and Sethi, R. and Ullman, J. and Lam, M.}, year = 2006}`, "ASUL06"},
+		{`@book{a, author = {A. Aho and R. Sethi and J. Ullman and M. Lam and B. Kernighan}, year = 2006}`, "ASU+06"},
+		{`@book{a, author = {Smith, J. and others}, year = 2010}`, "S+10"},
+		{`@book{a, author = {Smith, J. and Jones, K. and others}, year = 2010}`, "SJ+10"},
+		{`@book{a, author = {{\"O}zge Aks{\i}n}, year = 1999}`, "Aks99"},
+		{`@book{a, editor = {Editor, E.}, year = 2001}`, "Edi01"},
+		{`@misc{wiki, key = {Wikipedia}}`, "Wik"},
+		{`@misc{wiki, title = {No author}}`, "wik"},
+	}
+	for _, c := range cases {
+		entry := MustParse(t, c.Source).Entries[0]
+		if got := entry.AlphaLabel(); got != c.Label {
+			t.Errorf("%s: got label %q, expected %q", c.Source, got, c.Label)
+		}
+	}
+}
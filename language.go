@@ -0,0 +1,134 @@
+package bibtex
+
+import (
+	"strings"
+)
+
+// languages lists BCP 47 codes with the babel language names that denote
+// them. The first name is the canonical one.
+var languages = []struct {
+	code  string
+	names []string
+}{
+	{"en", []string{"english"}},
+	{"en-US", []string{"american", "usenglish", "americanenglish"}},
+	{"en-GB", []string{"british", "ukenglish", "britishenglish"}},
+	{"en-AU", []string{"australian"}},
+	{"en-CA", []string{"canadian"}},
+	{"en-NZ", []string{"newzealand"}},
+	{"de", []string{"german", "ngerman", "deutsch"}},
+	{"de-AT", []string{"austrian", "naustrian"}},
+	{"de-CH", []string{"swissgerman", "nswissgerman"}},
+	{"fr", []string{"french", "francais", "frenchb"}},
+	{"fr-CA", []string{"canadien", "acadian"}},
+	{"es", []string{"spanish"}},
+	{"ca", []string{"catalan"}},
+	{"it", []string{"italian"}},
+	{"pt", []string{"portuguese", "portuges"}},
+	{"pt-BR", []string{"brazilian", "brazil"}},
+	{"nl", []string{"dutch"}},
+	{"sv", []string{"swedish"}},
+	{"da", []string{"danish"}},
+	{"nb", []string{"norsk", "norwegian", "bokmal"}},
+	{"nn", []string{"nynorsk"}},
+	{"fi", []string{"finnish"}},
+	{"is", []string{"icelandic"}},
+	{"pl", []string{"polish"}},
+	{"cs", []string{"czech"}},
+	{"sk", []string{"slovak"}},
+	{"sl", []string{"slovene", "slovenian"}},
+	{"hr", []string{"croatian"}},
+	{"sr", []string{"serbian"}},
+	{"bg", []string{"bulgarian"}},
+	{"ro", []string{"romanian"}},
+	{"hu", []string{"hungarian", "magyar"}},
+	{"et", []string{"estonian"}},
+	{"lv", []string{"latvian"}},
+	{"lt", []string{"lithuanian"}},
+	{"ru", []string{"russian"}},
+	{"uk", []string{"ukrainian"}},
+	{"el", []string{"greek"}},
+	{"tr", []string{"turkish"}},
+	{"he", []string{"hebrew"}},
+	{"ar", []string{"arabic"}},
+	{"ga", []string{"irish"}},
+	{"cy", []string{"welsh"}},
+	{"eu", []string{"basque"}},
+	{"gl", []string{"galician"}},
+	{"la", []string{"latin"}},
+	{"ja", []string{"japanese"}},
+	{"zh", []string{"chinese"}},
+	{"ko", []string{"korean"}},
+}
+
+// Language returns the language of the entry as a BCP 47 code, such as de or
+// en-US. It is taken from the langid field, or else the first language in the
+// language field. Babel names such as ngerman are mapped to their code, and
+// codes are given canonical case. Unrecognised values are returned as they
+// are.
+func (entry *BibEntry) Language() string {
+	s := entry.languageField()
+	for _, lang := range languages {
+		if containsFold(lang.names, s) {
+			return lang.code
+		}
+	}
+	if code, ok := canonicalLanguageCode(s); ok {
+		return code
+	}
+	return s
+}
+
+// LanguageName returns the language of the entry as a canonical babel name,
+// such as german or american. Codes are mapped to names, falling back to the
+// primary language subtag, so de-DE is german. Unrecognised values are
+// returned as they are.
+func (entry *BibEntry) LanguageName() string {
+	code := entry.Language()
+	for _, c := range []string{code, strings.SplitN(code, "-", 2)[0]} {
+		for _, lang := range languages {
+			if strings.EqualFold(lang.code, c) {
+				return lang.names[0]
+			}
+		}
+	}
+	return code
+}
+
+// languageField returns the raw language of the entry.
+func (entry *BibEntry) languageField() string {
+	if langid := fieldString(entry, "langid"); langid != "" {
+		return langid
+	}
+	s := fieldString(entry, "language")
+	if names := splitNames(s); len(names) > 0 {
+		return names[0]
+	}
+	return s
+}
+
+// canonicalLanguageCode returns s in the canonical case of a BCP 47 tag: the
+// language lowercase, scripts titlecase and regions uppercase. Underscores are
+// accepted as separators. It returns false if s does not look like a tag.
+func canonicalLanguageCode(s string) (string, bool) {
+	subtags := strings.Split(strings.Replace(s, "_", "-", -1), "-")
+	for i, subtag := range subtags {
+		if subtag == "" || len(subtag) > 8 || strings.IndexFunc(subtag, func(ch rune) bool { return !isAlphanum(ch) }) >= 0 {
+			return "", false
+		}
+		switch {
+		case i == 0:
+			if len(subtag) < 2 || len(subtag) > 3 {
+				return "", false
+			}
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 4 && isAlpha(rune(subtag[0])):
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) == 2 || len(subtag) == 3 && isDigit(rune(subtag[0])):
+			subtags[i] = strings.ToUpper(subtag)
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+	return strings.Join(subtags, "-"), true
+}
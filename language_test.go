@@ -0,0 +1,32 @@
+package bibtex
+
+import "testing"
+
+func TestLanguage(t *testing.T) {
+	cases := []struct {
+		Src  string
+		Code string
+		Name string
+	}{
+		{`@misc{a, language = {english}}`, "en", "english"},
+		{`@misc{a, language = {en-US}}`, "en-US", "american"},
+		{`@misc{a, language = {EN_us}}`, "en-US", "american"},
+		{`@misc{a, langid = {ngerman}}`, "de", "german"},
+		{`@misc{a, language = {German}}`, "de", "german"},
+		{`@misc{a, language = {de-DE}}`, "de-DE", "german"},
+		{`@misc{a, language = {zh-hant-tw}}`, "zh-Hant-TW", "chinese"},
+		{`@misc{a, langid = {french}, language = {english}}`, "fr", "french"},
+		{`@misc{a, language = {english and french}}`, "en", "english"},
+		{`@misc{a, language = {Klingon}}`, "Klingon", "Klingon"},
+		{`@misc{a, title = {T}}`, "", ""},
+	}
+	for _, c := range cases {
+		entry := MustParse(t, c.Src).Entries[0]
+		if got := entry.Language(); got != c.Code {
+			t.Errorf("%s: got language %q, expected %q", c.Src, got, c.Code)
+		}
+		if got := entry.LanguageName(); got != c.Name {
+			t.Errorf("%s: got language name %q, expected %q", c.Src, got, c.Name)
+		}
+	}
+}
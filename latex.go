@@ -0,0 +1,377 @@
+package bibtex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// accents maps accent commands to the base letters they apply to and the
+// corresponding accented letters.
+var accents = map[string][2]string{
+	"'":  {"aeiouyAEIOUYcnszCNSZ", "áéíóúýÁÉÍÓÚÝćńśźĆŃŚŹ"},
+	"`":  {"aeiouAEIOU", "àèìòùÀÈÌÒÙ"},
+	"^":  {"aeiouAEIOUcgsCGS", "âêîôûÂÊÎÔÛĉĝŝĈĜŜ"},
+	"\"": {"aeiouyAEIOUY", "äëïöüÿÄËÏÖÜŸ"},
+	"~":  {"anoANO", "ãñõÃÑÕ"},
+	"=":  {"aeiouAEIOU", "āēīōūĀĒĪŌŪ"},
+	".":  {"zceZCEI", "żċėŻĊĖİ"},
+	"u":  {"agAG", "ăğĂĞ"},
+	"v":  {"cdenrstzCDENRSTZ", "čďěňřšťžČĎĚŇŘŠŤŽ"},
+	"H":  {"ouOU", "őűŐŰ"},
+	"c":  {"cstCST", "çşţÇŞŢ"},
+	"k":  {"aeAE", "ąęĄĘ"},
+	"r":  {"auAU", "åůÅŮ"},
+}
+
+// symbols maps commands to the text they produce.
+var symbols = map[string]string{
+	"ss": "ß", "o": "ø", "O": "Ø", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ",
+	"aa": "å", "AA": "Å", "l": "ł", "L": "Ł", "i": "ı", "j": "ȷ",
+	"&": "&", "%": "%", "$": "$", "#": "#", "_": "_", "{": "{", "}": "}",
+	"\\": " ", " ": " ", "ldots": "…", "textendash": "–", "textemdash": "—",
+	"textbackslash": "\\",
+}
+
+// DecodeLaTeX converts LaTeX markup in a field value to plain unicode text.
+// Accents and escaped characters are decoded, formatting commands such as
+// \textbf are removed leaving their arguments, and grouping braces are
+// dropped.
+func DecodeLaTeX(s string) string {
+	d := &latexDecoder{s: []rune(s)}
+	return d.decode(false)
+}
+
+type latexDecoder struct {
+	s          []rune
+	i          int
+	paragraphs bool // Decode \par and \\ as paragraph breaks.
+}
+
+// decode decodes until the end of input, or the end of the current group if
+// group is set.
+func (d *latexDecoder) decode(group bool) string {
+	var buf strings.Builder
+	for d.i < len(d.s) {
+		switch ch := d.s[d.i]; ch {
+		case '{':
+			d.i++
+			buf.WriteString(d.decode(true))
+		case '}':
+			d.i++
+			if group {
+				return buf.String()
+			}
+		case '~':
+			d.i++
+			buf.WriteRune(' ')
+		case '\\':
+			d.i++
+			buf.WriteString(d.command())
+		default:
+			d.i++
+			buf.WriteRune(ch)
+		}
+	}
+	return buf.String()
+}
+
+// command decodes a command, with the backslash already consumed.
+func (d *latexDecoder) command() string {
+	if d.i >= len(d.s) {
+		return ""
+	}
+	var name string
+	if ch := d.s[d.i]; unicode.IsLetter(ch) {
+		start := d.i
+		for d.i < len(d.s) && unicode.IsLetter(d.s[d.i]) {
+			d.i++
+		}
+		name = string(d.s[start:d.i])
+		d.skipSpace()
+	} else {
+		d.i++
+		name = string(ch)
+	}
+
+	if d.paragraphs && (name == "par" || name == "\\") {
+		return "\n\n"
+	}
+	if accent, ok := accents[name]; ok {
+		return applyAccent(accent, d.argument())
+	}
+	if sym, ok := symbols[name]; ok {
+		return sym
+	}
+	// Formatting command: drop it and keep any argument.
+	return ""
+}
+
+// argument decodes the argument of an accent command.
+func (d *latexDecoder) argument() string {
+	d.skipSpace()
+	if d.i >= len(d.s) {
+		return ""
+	}
+	switch ch := d.s[d.i]; ch {
+	case '{':
+		d.i++
+		return d.decode(true)
+	case '\\':
+		d.i++
+		return d.command()
+	default:
+		d.i++
+		return string(ch)
+	}
+}
+
+func (d *latexDecoder) skipSpace() {
+	for d.i < len(d.s) && unicode.IsSpace(d.s[d.i]) {
+		d.i++
+	}
+}
+
+// applyAccent accents the first letter of arg, if the accent applies to it.
+func applyAccent(accent [2]string, arg string) string {
+	r := []rune(arg)
+	if len(r) == 0 {
+		return arg
+	}
+	if r[0] == 'ı' {
+		r[0] = 'i'
+	}
+	base, accented := []rune(accent[0]), []rune(accent[1])
+	for i, b := range base {
+		if b == r[0] {
+			r[0] = accented[i]
+			break
+		}
+	}
+	return string(r)
+}
+
+// letterSymbols are the commands in symbols that produce letters.
+var letterSymbols = map[string]bool{
+	"ss": true, "o": true, "O": true, "ae": true, "AE": true, "oe": true, "OE": true,
+	"aa": true, "AA": true, "l": true, "L": true, "i": true, "j": true,
+}
+
+// encodings maps non-ASCII letters to the LaTeX that produces them.
+var encodings = map[rune]string{}
+
+func init() {
+	for name, accent := range accents {
+		base, accented := []rune(accent[0]), []rune(accent[1])
+		for i, ch := range accented {
+			if unicode.IsLetter([]rune(name)[0]) {
+				encodings[ch] = "{\\" + name + "{" + string(base[i]) + "}}"
+			} else {
+				encodings[ch] = "{\\" + name + string(base[i]) + "}"
+			}
+		}
+	}
+	for name := range letterSymbols {
+		encodings[[]rune(symbols[name])[0]] = "{\\" + name + "}"
+	}
+}
+
+// EncodeLaTeX replaces the accented and other non-ASCII letters in s that
+// DecodeLaTeX understands with LaTeX commands, such as "é" with {\'e} and "ß"
+// with {\ss}. Other characters, including LaTeX markup, are left unchanged.
+func EncodeLaTeX(s string) string {
+	var buf strings.Builder
+	for _, ch := range s {
+		if enc, ok := encodings[ch]; ok {
+			buf.WriteString(enc)
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+	return buf.String()
+}
+
+// decodeAccents replaces the accent commands and letter symbols in s, such as
+// {\'e} and \ss, with the letters they produce. Unlike DecodeLaTeX, other
+// markup and braces are left unchanged.
+func decodeAccents(s string) string {
+	r := []rune(s)
+	var buf strings.Builder
+	for i := 0; i < len(r); {
+		if text, n := accentAt(r[i:]); n > 0 {
+			buf.WriteString(text)
+			i += n
+			continue
+		}
+		buf.WriteRune(r[i])
+		i++
+	}
+	return buf.String()
+}
+
+// accentAt decodes an accent command or letter symbol at the start of r,
+// optionally enclosed in braces. Returns the letter and the number of runes
+// consumed, which is zero if r does not start with one.
+func accentAt(r []rune) (string, int) {
+	if len(r) > 0 && r[0] == '{' {
+		if text, n := accentAt(r[1:]); n > 0 && n+1 < len(r) && r[n+1] == '}' {
+			return text, n + 2
+		}
+		return "", 0
+	}
+	if len(r) < 2 || r[0] != '\\' {
+		return "", 0
+	}
+	i := 1
+	if unicode.IsLetter(r[i]) {
+		for i < len(r) && unicode.IsLetter(r[i]) {
+			i++
+		}
+	} else {
+		i++
+	}
+	name := string(r[1:i])
+	letter := unicode.IsLetter(r[1])
+	if accent, ok := accents[name]; ok {
+		for letter && i < len(r) && r[i] == ' ' {
+			i++
+		}
+		arg, n := "", 0
+		switch {
+		case i+2 < len(r) && r[i] == '{' && r[i+2] == '}' && unicode.IsLetter(r[i+1]):
+			arg, n = string(r[i+1]), 3
+		case i+3 < len(r) && r[i] == '{' && r[i+1] == '\\' && r[i+2] == 'i' && r[i+3] == '}':
+			arg, n = "ı", 4
+		case i+1 < len(r) && r[i] == '\\' && r[i+1] == 'i' && (i+2 == len(r) || !unicode.IsLetter(r[i+2])):
+			arg, n = "ı", 2
+		case i < len(r) && unicode.IsLetter(r[i]):
+			arg, n = string(r[i]), 1
+		}
+		if text := applyAccent(accent, arg); n > 0 && text != arg {
+			return text, i + n
+		}
+		return "", 0
+	}
+	if letterSymbols[name] {
+		if i+1 < len(r) && r[i] == '{' && r[i+1] == '}' {
+			i += 2
+		}
+		for i < len(r) && r[i] == ' ' {
+			i++
+		}
+		return symbols[name], i
+	}
+	return "", 0
+}
+
+// specials escapes the characters that have a meaning in LaTeX or BibTeX
+// markup, so that plain text is read back unchanged by DecodeLaTeX.
+var specials = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`,
+	"_", `\_`, "{", `\{`, "}", `\}`,
+)
+
+// escapeText converts plain text to a field value by escaping the characters
+// special to LaTeX and BibTeX, including braces.
+func escapeText(s string) string {
+	return specials.Replace(s)
+}
+
+// EscapeAmpersands escapes bare & characters in s as \&. Ampersands that are
+// already escaped are not escaped again. DecodeLaTeX reverses the escaping.
+func EscapeAmpersands(s string) string {
+	var buf strings.Builder
+	escaped := false
+	for _, ch := range s {
+		if ch == '&' && !escaped {
+			buf.WriteRune('\\')
+		}
+		escaped = ch == '\\' && !escaped
+		buf.WriteRune(ch)
+	}
+	return buf.String()
+}
+
+// punctuation replaces typographic punctuation with its LaTeX input form.
+var punctuation = strings.NewReplacer(
+	"“", "``", "”", "''", "„", ",,", "‘", "`", "’", "'",
+	"…", `\ldots{}`, "–", "--", "—", "---",
+)
+
+// NormalizePunctuation replaces curly quotes, ellipses and dashes in s, as are
+// common in text pasted from PDFs, with their LaTeX equivalents: curly quotes
+// become backtick and apostrophe pairs, … becomes \ldots{}, and en and em
+// dashes become -- and ---.
+func NormalizePunctuation(s string) string {
+	return punctuation.Replace(s)
+}
+
+// abbreviations are words whose trailing period TrimTrailingPunctuation keeps.
+var abbreviations = map[string]bool{
+	"inc": true, "ltd": true, "co": true, "corp": true, "jr": true, "sr": true,
+	"al": true, "etc": true, "vs": true, "vol": true, "no": true, "pp": true,
+	"ed": true, "eds": true, "dept": true, "univ": true, "st": true,
+}
+
+// TrimTrailingPunctuation removes a single trailing period, comma or
+// semicolon from s, along with the space before it. A period is kept if it
+// ends an abbreviation: a single letter, as in an initial, a word with other
+// periods, as in U.S., a known abbreviation such as Inc. or et al., or an
+// ellipsis.
+func TrimTrailingPunctuation(s string) string {
+	trimmed := strings.TrimRightFunc(s, unicode.IsSpace)
+	if trimmed == "" {
+		return s
+	}
+	last := trimmed[len(trimmed)-1]
+	if !strings.ContainsRune(".,;", rune(last)) {
+		return s
+	}
+	rest := trimmed[:len(trimmed)-1]
+	if last == '.' {
+		word := rest[strings.LastIndexFunc(rest, func(ch rune) bool { return unicode.IsSpace(ch) || ch == '{' || ch == '~' })+1:]
+		if strings.HasSuffix(rest, ".") || strings.Contains(word, ".") || abbreviations[strings.ToLower(word)] ||
+			utf8.RuneCountInString(word) == 1 {
+			return s
+		}
+	}
+	return strings.TrimRightFunc(rest, unicode.IsSpace)
+}
+
+// collapseSpace replaces runs of whitespace with a single space and trims the
+// result.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// foldings maps non-ASCII letters to ASCII replacements, other than those
+// derived from the accent table.
+var foldings = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L", 'ı': "i", 'ȷ': "j", 'đ': "d", 'Đ': "D", 'þ': "th", 'Þ': "Th",
+	'’': "'", '‘': "'", '“': "\"", '”': "\"", '–': "-", '—': "-",
+}
+
+func init() {
+	for _, accent := range accents {
+		base, accented := []rune(accent[0]), []rune(accent[1])
+		for i, ch := range accented {
+			foldings[ch] = string(base[i])
+		}
+	}
+}
+
+// ASCIIFold replaces accented and other non-ASCII letters in s with their
+// closest ASCII equivalents, such as "Müller" to "Muller". Characters with no
+// known equivalent are left unchanged.
+func ASCIIFold(s string) string {
+	var buf strings.Builder
+	for _, ch := range s {
+		if f, ok := foldings[ch]; ok {
+			buf.WriteString(f)
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,162 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeLaTeX(t *testing.T) {
+	cases := map[string]string{
+		`Aks{\i}n, {\"O}zge`:      "Aksın, Özge",
+		`{\c{C}}etinkaya`:         "Çetinkaya",
+		`Ng, Nich\'{o}las`:        "Ng, Nichólas",
+		`\textbf{Hello} World`:    "Hello World",
+		`Taylor \& Francis`:       "Taylor & Francis",
+		`Angew.~Chem.`:            "Angew. Chem.",
+		`Schr\"odinger`:           "Schrödinger",
+		`\'\i`:                    "í",
+		`The {DNA} Helix`:         "The DNA Helix",
+		`50\% off`:                "50% off",
+		`Stra\ss e`:               "Straße",
+		`\v{S}koda`:               "Škoda",
+		`plain text`:              "plain text",
+		`unbalanced {brace`:       "unbalanced brace",
+		`trailing backslash \`:    "trailing backslash ",
+		`{\"{o}}ne \={a}nd {\aa}`: "öne ānd å",
+	}
+	for input, expected := range cases {
+		if got := DecodeLaTeX(input); got != expected {
+			t.Errorf("DecodeLaTeX(%q) = %q; expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestDecodeAccents(t *testing.T) {
+	cases := map[string]string{
+		`Aks{\i}n, {\"O}zge`:       "Aksın, Özge",
+		`{\c{C}}etinkaya`:          "Çetinkaya",
+		`Nich\'{o}las \'\i`:        "Nichólas í",
+		`Stra\ss e {\ss}`:          "Straße ß",
+		`\v{S}koda \c cat`:         "Škoda çat",
+		`The {DNA} \textbf{Helix}`: "The {DNA} \\textbf{Helix}",
+		`{\'et\'e} \'1`:            "{été} \\'1",
+	}
+	for input, expected := range cases {
+		if got := decodeAccents(input); got != expected {
+			t.Errorf("decodeAccents(%q) = %q; expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestEncodeLaTeX(t *testing.T) {
+	cases := map[string]string{
+		"Müller":           `M{\"u}ller`,
+		"Škoda":            `{\v{S}}koda`,
+		"Straße, Ørsted":   `Stra{\ss}e, {\O}rsted`,
+		"Ångström":         `{\AA}ngstr{\"o}m`,
+		"plain {DNA} text": "plain {DNA} text",
+	}
+	for input, expected := range cases {
+		got := EncodeLaTeX(input)
+		if got != expected {
+			t.Errorf("EncodeLaTeX(%q) = %q; expected %q", input, got, expected)
+		}
+		if decoded := decodeAccents(got); decoded != input {
+			t.Errorf("decodeAccents(%q) = %q; expected %q", got, decoded, input)
+		}
+	}
+}
+
+func TestEscapeAmpersands(t *testing.T) {
+	cases := map[string]string{
+		`Taylor & Francis`:   `Taylor \& Francis`,
+		`Taylor \& Francis`:  `Taylor \& Francis`,
+		`A & B \& C`:         `A \& B \& C`,
+		`line\\& break`:      `line\\\& break`,
+		`no ampersand here`:  `no ampersand here`,
+		`&leading&trailing&`: `\&leading\&trailing\&`,
+	}
+	for input, expected := range cases {
+		if got := EscapeAmpersands(input); got != expected {
+			t.Errorf("EscapeAmpersands(%q) = %q; expected %q", input, got, expected)
+		}
+	}
+}
+
+// Tests a publisher name round trips through BibTeX and plain text forms.
+func TestAmpersandRoundTrip(t *testing.T) {
+	for _, publisher := range []string{`Taylor & Francis`, `Taylor \& Francis`} {
+		src := "@book{b, publisher = {" + publisher + "}}"
+		expect := "@book{b,\n    publisher = \"Taylor \\& Francis\",\n}\n"
+		AssertFormat(t, &Formatter{EscapeAmpersands: true}, src, expect)
+
+		bib := MustParse(t, expect)
+		if got := DecodeLaTeX(bib.Entries[0].Fields["publisher"].String()); got != "Taylor & Francis" {
+			t.Errorf("got plain text %q", got)
+		}
+	}
+}
+
+func TestEscapeAmpersandsVerbatimFields(t *testing.T) {
+	src := "@misc{m, url = {http://x.com/?a=1&b=2}, doi = {10.1/a&b}, note = {A & B}}"
+	expect := "@misc{m,\n    url  = \"http://x.com/?a=1&b=2\",\n    doi  = \"10.1/a&b\",\n    note = \"A \\& B\",\n}\n"
+	AssertFormat(t, &Formatter{EscapeAmpersands: true}, src, expect)
+}
+
+func TestASCIIFold(t *testing.T) {
+	cases := map[string]string{
+		"Müller":    "Muller",
+		"Çetinkaya": "Cetinkaya",
+		"Straße":    "Strasse",
+		"Łódź":      "Lodz",
+		"Ørsted":    "Orsted",
+		"plain":     "plain",
+		"日本":        "日本",
+	}
+	for input, expected := range cases {
+		if got := ASCIIFold(input); got != expected {
+			t.Errorf("ASCIIFold(%q) = %q; expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestNormalizePunctuation(t *testing.T) {
+	input := "“Smart” quotes — it’s 1990–2000…"
+	expect := "``Smart'' quotes --- it's 1990--2000\\ldots{}"
+	if got := NormalizePunctuation(input); got != expect {
+		t.Errorf("NormalizePunctuation(%q) = %q; expected %q", input, got, expect)
+	}
+}
+
+func TestParserNormalizePunctuation(t *testing.T) {
+	p := &Parser{NormalizePunctuation: true}
+	bib, err := p.Parse(strings.NewReader(`@misc{a, title = {“Quoted” — title}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bib.Entries[0].Fields["title"].String(); got != "``Quoted'' --- title" {
+		t.Errorf("got title %q", got)
+	}
+}
+
+func TestTrimTrailingPunctuation(t *testing.T) {
+	cases := map[string]string{
+		"A Study.":           "A Study",
+		"A Study, ":          "A Study",
+		"Journal of Things;": "Journal of Things",
+		"Once.,":             "Once.",
+		"Acme Inc.":          "Acme Inc.",
+		"Smith et al.":       "Smith et al.",
+		"Vitamin C.":         "Vitamin C.",
+		"Made in the U.S.":   "Made in the U.S.",
+		"And so on...":       "And so on...",
+		"{Proc. IEEE}":       "{Proc. IEEE}",
+		"Plain title":        "Plain title",
+		"":                   "",
+	}
+	for input, expected := range cases {
+		if got := TrimTrailingPunctuation(input); got != expected {
+			t.Errorf("TrimTrailingPunctuation(%q) = %q; expected %q", input, got, expected)
+		}
+	}
+}
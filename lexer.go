@@ -2,27 +2,254 @@
 
 package bibtex
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"strings"
+)
 
 // Lexer for bibtex.
 type Lexer struct {
 	scanner *Scanner
-	Errors  chan error
+	parser  *Parser
+	pending *lexeme // Token read ahead of the parser.
+	bib     *BibTex // Bibliography being parsed.
+
+	comments map[int][]string     // Leading comments, by offset of their entry.
+	validate bool                 // Check syntax only, without building entries.
+	emit     func(*BibEntry) bool // If set, receives entries in place of bib.
+	forward  []*forwardRef        // String variables used before definition.
+	keys     map[string]TokenPos  // Positions of citation keys, in lowercase.
+	stopped  bool                 // Emit asked for parsing to stop.
+	Errors   chan error
+}
+
+// forwardRef is a string variable used before it is defined, to be resolved
+// at the end of the input.
+type forwardRef struct {
+	v   *BibVar
+	pos TokenPos // Position of the first use.
+}
+
+// lexeme is a scanned token.
+type lexeme struct {
+	tok    Token
+	lit    string
+	offset int
+	quoted bool     // Literal was enclosed in double quotes.
+	pos    TokenPos // Position of the start of the token.
 }
 
 // NewLexer returns a new yacc-compatible lexer.
 func NewLexer(r io.Reader) *Lexer {
-	return &Lexer{scanner: NewScanner(r), Errors: make(chan error, 1)}
+	return newLexer(NewScanner(r))
+}
+
+func newLexer(s *Scanner) *Lexer {
+	return &Lexer{scanner: s, parser: &Parser{}, Errors: make(chan error, 1)}
 }
 
 // Lex is provided for yacc-compatible parser.
 func (l *Lexer) Lex(yylval *bibtexSymType) int {
-	token, strval := l.scanner.Scan()
-	yylval.strval = strval
-	return int(token)
+	lx := l.next()
+	yylval.strval = lx.lit
+	yylval.offset = lx.offset
+	yylval.quoted = lx.quoted
+	yylval.pos = lx.pos
+	return int(lx.tok)
 }
 
-// Error handles error.
+// next returns the next token for the parser, skipping entries of types the
+// parser is not interested in.
+func (l *Lexer) next() lexeme {
+	if lx := l.pending; lx != nil {
+		l.pending = nil
+		return *lx
+	}
+	if l.stopped {
+		return lexeme{tok: ILLEGAL}
+	}
+	for {
+		tok, lit := l.scanner.Scan()
+		if tok == ILLEGAL && l.scanner.err != nil {
+			l.report(l.scanner.err)
+		}
+		lx := lexeme{tok: tok, lit: lit, offset: l.scanner.start, quoted: l.scanner.quoted, pos: l.scanner.startPos}
+		if tok == ATSIGN && l.scanner.leading != nil {
+			if l.comments == nil {
+				l.comments = map[int][]string{}
+			}
+			l.comments[lx.offset] = l.scanner.leading
+		}
+		if tok != ATSIGN || len(l.parser.Types) == 0 {
+			return lx
+		}
+		tok, lit = l.scanner.Scan()
+		if tok == BAREIDENT && !l.parser.wantType(lit) {
+			if !l.scanner.skipEntry() {
+				l.fail(ErrUnbalancedBrace, "unterminated entry: "+lit)
+			}
+			continue
+		}
+		l.pending = &lexeme{tok: tok, lit: lit, offset: l.scanner.start, pos: l.scanner.startPos}
+		return lx
+	}
+}
+
+// Error handles a syntax error from the parser. Only the first error is kept.
 func (l *Lexer) Error(err string) {
-	l.Errors <- &ErrParse{Err: err, Pos: l.scanner.pos}
+	l.fail(ErrUnexpectedToken, err)
+}
+
+// fail reports an error of the given kind at the current position.
+func (l *Lexer) fail(kind error, err string) {
+	l.report(&ErrParse{Err: err, Pos: l.scanner.pos, Kind: kind})
+}
+
+// report records an error, unless one has already been recorded.
+func (l *Lexer) report(err error) {
+	select {
+	case l.Errors <- err:
+	default:
+	}
+}
+
+// addEntry adds a parsed entry to the bibliography, or passes it to emit if
+// set. Parsing stops if emit returns false.
+func (l *Lexer) addEntry(entry *BibEntry) {
+	if l.emit == nil {
+		l.bib.AddEntry(entry)
+	} else if !l.emit(entry) {
+		l.stopped = true
+	}
+}
+
+// stringVar looks up a string variable for the parser. An undefined variable
+// is an error with StrictMacroOrder, and otherwise is resolved by
+// resolveForward once the whole input is parsed.
+func (l *Lexer) stringVar(key string) BibString {
+	if l.validate {
+		return NewBibConst("")
+	}
+	if bv := l.bib.GetStringVar(key); bv != nil {
+		return bv
+	}
+	if !l.parser.StrictMacroOrder {
+		for _, ref := range l.forward {
+			if strings.EqualFold(ref.v.Key, key) {
+				return ref.v
+			}
+		}
+		ref := &forwardRef{v: &BibVar{Key: key, Value: NewBibConst("")}, pos: l.scanner.pos}
+		l.forward = append(l.forward, ref)
+		return ref.v
+	}
+	l.scanner.logf("%s: %s", ErrUnknownStringVar, key)
+	l.fail(ErrUnknownStringVar, fmt.Sprintf("%s: %s", ErrUnknownStringVar, key))
+	return NewBibConst("")
+}
+
+// resolveForward gives the string variables used before their definition the
+// value they were finally defined with, reporting an error for any that were
+// never defined.
+func (l *Lexer) resolveForward() {
+	for _, ref := range l.forward {
+		if bv := l.bib.GetStringVar(ref.v.Key); bv != nil {
+			ref.v.Value = bv.Value
+			continue
+		}
+		msg := fmt.Sprintf("%s: %s", ErrUnknownStringVar, ref.v.Key)
+		l.scanner.logf("%s", msg)
+		l.report(&ErrParse{Err: msg, Pos: ref.pos, Kind: ErrUnknownStringVar})
+	}
+	l.forward = nil
+}
+
+// defineStringVar defines a string variable for the parser. Redefinitions are
+// an error with StrictStrings, and otherwise override with a warning.
+func (l *Lexer) defineStringVar(key string, val BibString) {
+	if l.validate {
+		return
+	}
+	if _, ok := l.bib.StringVar[strings.ToLower(key)]; ok {
+		msg := fmt.Sprintf("%s: %s", ErrRedefinedStringVar, key)
+		if l.parser.StrictStrings {
+			l.fail(ErrRedefinedStringVar, msg)
+			return
+		}
+		l.scanner.logf("%s", msg)
+		l.bib.Warnings = append(l.bib.Warnings, &ErrParse{Err: msg, Pos: l.scanner.pos, Kind: ErrRedefinedStringVar})
+	}
+	l.bib.AddStringVar(key, val)
+}
+
+// checkKey records the citation key at pos, reporting a repeated key as an
+// error with StrictKeys and otherwise as a warning.
+func (l *Lexer) checkKey(key string, pos TokenPos) {
+	if l.keys == nil {
+		l.keys = map[string]TokenPos{}
+	}
+	lower := strings.ToLower(key)
+	prev, ok := l.keys[lower]
+	if !ok {
+		l.keys[lower] = pos
+		return
+	}
+	err := &ErrParse{Pos: pos, Err: fmt.Sprintf("%s: %s, previously at %s", ErrDuplicateKey, key, prev), Kind: ErrDuplicateKey}
+	if l.parser.StrictKeys {
+		l.report(err)
+		return
+	}
+	l.scanner.logf("%s", err.Err)
+	l.bib.Warnings = append(l.bib.Warnings, err)
+}
+
+// entry builds an entry for the parser from its parsed fields, spanning the
+// given source offsets. The key is at keyPos.
+func (l *Lexer) entry(start, end int, entryType, key string, keyPos TokenPos, tags []*bibTag) *BibEntry {
+	if l.validate {
+		return &BibEntry{}
+	}
+	l.checkKey(key, keyPos)
+	entry := NewBibEntry(entryType, key)
+	entry.RawType = entryType
+	if t := canonicalType(entry.Type); t != entry.Type {
+		entry.Type, entry.Alias = t, entry.Type
+	}
+	entry.start, entry.end = start, end
+	entry.LeadingComments = l.comments[start]
+	delete(l.comments, start)
+	seen := map[string]*bibTag{}
+	for _, t := range tags {
+		if prev, ok := seen[strings.ToLower(t.key)]; ok {
+			err := &ErrParse{
+				Pos:  t.pos,
+				Err:  fmt.Sprintf("%s: %s = %q, previously %q at %s", ErrDuplicateField, t.key, t.val.String(), prev.val.String(), prev.pos),
+				Kind: ErrDuplicateField,
+			}
+			if l.parser.StrictFields {
+				l.report(err)
+				break
+			}
+			l.scanner.logf("%s", err.Err)
+			l.bib.Warnings = append(l.bib.Warnings, err)
+			delete(entry.Fields, prev.key)
+		}
+		seen[strings.ToLower(t.key)] = t
+		val := t.val
+		if hook := l.parser.FieldHook; hook != nil {
+			s := val.String()
+			if h := hook(entry.Type, entry.CiteName, t.key, s); h != s {
+				val = NewBibConst(h)
+			}
+		}
+		if l.parser.NormalizePunctuation {
+			s := val.String()
+			if n := NormalizePunctuation(s); n != s {
+				val = NewBibConst(n)
+			}
+		}
+		entry.AddField(t.key, val)
+	}
+	return entry
 }
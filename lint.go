@@ -0,0 +1,383 @@
+package bibtex
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LintOptions selects the advisory checks run by Lint. All are off by default.
+type LintOptions struct {
+	Mojibake bool // Flag fields that appear to contain double-encoded UTF-8.
+
+	// UnprotectedCaps flags all-caps words in titles that are not protected
+	// by braces, and so may be lowercased by BibTeX styles.
+	UnprotectedCaps bool
+
+	// InlineMacros flags field values written out literally that match the
+	// expansion of a @string macro, which could be used instead.
+	InlineMacros bool
+
+	// SwappedAuthorTitle flags entries whose author reads like a title and
+	// whose title reads like a name, as from a mis-mapped import.
+	SwappedAuthorTitle bool
+
+	// BuriedURLs flags URLs in fields other than url and doi, such as a note,
+	// that likely belong in the url field.
+	BuriedURLs bool
+
+	// DoubleBraces flags titles wrapped whole in a second pair of braces, as
+	// in {{The Title}}, which stops styles from changing their case. See
+	// BibTex.UnwrapDoubleBraces.
+	DoubleBraces bool
+}
+
+// Lint runs the selected advisory checks over all entries. Issues are
+// reported as warnings.
+func (bib *BibTex) Lint(opts LintOptions) []*ValidationError {
+	var checks []entryCheck
+	if opts.Mojibake {
+		checks = append(checks, checkMojibake)
+	}
+	if opts.UnprotectedCaps {
+		checks = append(checks, checkUnprotectedCaps)
+	}
+	if opts.SwappedAuthorTitle {
+		checks = append(checks, checkSwappedAuthorTitle)
+	}
+	if opts.BuriedURLs {
+		checks = append(checks, checkBuriedURLs)
+	}
+	if opts.DoubleBraces {
+		checks = append(checks, checkDoubleBraces)
+	}
+	if opts.InlineMacros {
+		checks = append(checks, checkInlineMacros(bib.macroExpansions()))
+	}
+
+	var errs []*ValidationError
+	for _, entry := range bib.Entries {
+		for _, check := range checks {
+			errs = append(errs, check(nil, entry)...)
+		}
+	}
+	return errs
+}
+
+// sortedFields returns the field names of entry in sorted order.
+func sortedFields(entry *BibEntry) []string {
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func checkMojibake(v *Validator, entry *BibEntry) []*ValidationError {
+	var errs []*ValidationError
+	for _, key := range sortedFields(entry) {
+		if DetectMojibake(entry.Fields[key].String()) {
+			errs = append(errs, fieldError(entry, key, SeverityWarning, "possible mojibake, text may be double-encoded"))
+		}
+	}
+	return errs
+}
+
+// cp1252 maps the Windows-1252 characters in the range 0x80-0x9f to their
+// byte values.
+var cp1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// latin1Byte returns the byte that r would have been decoded from, had UTF-8
+// been misread as Latin-1 or Windows-1252.
+func latin1Byte(r rune) (byte, bool) {
+	if b, ok := cp1252[r]; ok {
+		return b, true
+	}
+	if 0xa0 <= r && r <= 0xff {
+		return byte(r), true
+	}
+	return 0, false
+}
+
+// DetectMojibake reports whether s likely contains UTF-8 text that was
+// decoded as Latin-1 or Windows-1252, such as "Ã©" for "é". It only reports a
+// match when a run of characters re-encodes to a valid multi-byte UTF-8
+// sequence, which is rare in legitimate text.
+func DetectMojibake(s string) bool {
+	runes := []rune(s)
+	for i, r := range runes {
+		lead, ok := latin1Byte(r)
+		if !ok || lead < 0xc2 || lead > 0xf4 {
+			continue
+		}
+		n := 2
+		switch {
+		case lead >= 0xf0:
+			n = 4
+		case lead >= 0xe0:
+			n = 3
+		}
+		if i+n > len(runes) {
+			continue
+		}
+		seq := []byte{lead}
+		for _, c := range runes[i+1 : i+n] {
+			b, ok := latin1Byte(c)
+			if !ok || b < 0x80 || b > 0xbf {
+				break
+			}
+			seq = append(seq, b)
+		}
+		if len(seq) == n && utf8.Valid(seq) {
+			return true
+		}
+	}
+	return false
+}
+
+// titleFields are the fields that BibTeX styles may change the case of.
+var titleFields = []string{"title", "booktitle"}
+
+func checkUnprotectedCaps(v *Validator, entry *BibEntry) []*ValidationError {
+	var errs []*ValidationError
+	for _, field := range titleFields {
+		value, ok := entry.Fields[field]
+		if !ok {
+			continue
+		}
+		words := unprotectedCaps(value.String())
+		if len(words) == 0 {
+			continue
+		}
+		suggest := make([]string, len(words))
+		for i, word := range words {
+			suggest[i] = "{" + word + "}"
+		}
+		msg := fmt.Sprintf("unprotected capitals, consider %s", strings.Join(suggest, ", "))
+		errs = append(errs, fieldError(entry, field, SeverityWarning, msg))
+	}
+	return errs
+}
+
+// unprotectedCaps returns the words in s with at least two letters, all
+// capitals, that are not enclosed in braces. Command names are ignored.
+func unprotectedCaps(s string) []string {
+	var words []string
+	depth := 0
+	command := false
+	var word []rune
+	flush := func() {
+		if len(word) >= 2 && !command && depth == 0 && isAllCaps(word) {
+			words = append(words, string(word))
+		}
+		word = word[:0]
+		command = false
+	}
+	for _, ch := range s {
+		if unicode.IsLetter(ch) {
+			word = append(word, ch)
+			continue
+		}
+		flush()
+		switch ch {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '\\':
+			command = true
+		}
+	}
+	flush()
+	return words
+}
+
+func checkDoubleBraces(v *Validator, entry *BibEntry) []*ValidationError {
+	var errs []*ValidationError
+	for _, field := range titleFields {
+		if _, ok := unwrapValue(entry.Fields[field]); ok {
+			errs = append(errs, fieldError(entry, field, SeverityWarning, "whole value is protected by double braces"))
+		}
+	}
+	return errs
+}
+
+// UnwrapDoubleBraces removes the inner braces from titles wrapped whole in
+// two pairs, rewriting {{The Title}} as {The Title}. Protection of parts of a
+// title, as in {The {DNA} Helix}, is left alone. Returns the number of
+// fields changed.
+func (bib *BibTex) UnwrapDoubleBraces() int {
+	n := 0
+	for _, entry := range bib.Entries {
+		for _, field := range titleFields {
+			if value, ok := unwrapValue(entry.Fields[field]); ok {
+				entry.Fields[field] = value
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// unwrapValue returns value without the brace group enclosing all of it, for
+// braced and quoted constants. Values using string variables are not changed.
+func unwrapValue(value BibString) (BibString, bool) {
+	switch v := value.(type) {
+	case BibConst:
+		if inner, ok := unwrapGroup(string(v)); ok {
+			return NewBibConst(inner), true
+		}
+	case BibQuoted:
+		if inner, ok := unwrapGroup(string(v)); ok {
+			return BibQuoted(inner), true
+		}
+	}
+	return value, false
+}
+
+// unwrapGroup returns the contents of s if s is a single brace group. Groups
+// starting with a command, such as {\"u}, are special characters rather than
+// protection and are not unwrapped.
+func unwrapGroup(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[1] == '\\' {
+		return "", false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // Skip escaped characters.
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[1:i], i == len(s)-1
+			}
+		}
+	}
+	return "", false
+}
+
+func isAllCaps(word []rune) bool {
+	for _, ch := range word {
+		if !unicode.IsUpper(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// macroExpansions maps the expansion of each @string macro to its name. Where
+// macros share an expansion, the first by name is used.
+func (bib *BibTex) macroExpansions() map[string]string {
+	keys := make([]string, 0, len(bib.StringVar))
+	for key := range bib.StringVar {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expansions := map[string]string{}
+	for _, key := range keys {
+		text := strings.TrimSpace(bib.StringVar[key].String())
+		if _, ok := expansions[text]; !ok && text != "" {
+			expansions[text] = bib.StringVar[key].Key
+		}
+	}
+	return expansions
+}
+
+// checkInlineMacros returns a check for literal values found in expansions.
+func checkInlineMacros(expansions map[string]string) entryCheck {
+	return func(v *Validator, entry *BibEntry) []*ValidationError {
+		var errs []*ValidationError
+		for _, field := range sortedFields(entry) {
+			switch value := entry.Fields[field].(type) {
+			case BibConst, BibQuoted:
+				if macro, ok := expansions[strings.TrimSpace(value.String())]; ok {
+					msg := fmt.Sprintf("value matches @string %s, consider using the macro", macro)
+					errs = append(errs, fieldError(entry, field, SeverityWarning, msg))
+				}
+			}
+		}
+		return errs
+	}
+}
+
+func checkSwappedAuthorTitle(v *Validator, entry *BibEntry) []*ValidationError {
+	author, ok := entry.Fields["author"]
+	if !ok || !looksLikeSentence(author.String()) {
+		return nil
+	}
+	title, ok := entry.Fields["title"]
+	if !ok || !looksLikeName(title.String()) {
+		return nil
+	}
+	return []*ValidationError{fieldError(entry, "author", SeverityWarning, "author looks like a title and title like a name, they may be swapped")}
+}
+
+// looksLikeSentence reports whether s reads as a phrase rather than a list of
+// names: several words, at least three of them lowercase, with no commas and
+// no "and" separators.
+func looksLikeSentence(s string) bool {
+	if strings.Contains(s, ",") {
+		return false
+	}
+	words := strings.Fields(DecodeLaTeX(s))
+	lower := 0
+	for _, word := range words {
+		if strings.EqualFold(word, "and") {
+			return false
+		}
+		if r, _ := utf8.DecodeRuneInString(word); unicode.IsLower(r) {
+			lower++
+		}
+	}
+	return len(words) >= 4 && lower >= 3
+}
+
+// looksLikeName reports whether s reads as a single personal name, such as
+// "Jane Smith", "J. R. Smith" or "Smith, Jane": two to four capitalized words.
+func looksLikeName(s string) bool {
+	s = DecodeLaTeX(s)
+	if strings.Count(s, ",") > 1 || strings.ContainsAny(s, ":;?!") {
+		return false
+	}
+	words := strings.Fields(strings.Replace(s, ",", " ", -1))
+	if len(words) < 2 || len(words) > 4 {
+		return false
+	}
+	for _, word := range words {
+		if r, _ := utf8.DecodeRuneInString(word); !unicode.IsUpper(r) || strings.EqualFold(word, "and") {
+			return false
+		}
+	}
+	return true
+}
+
+// buriedURLRe matches a web address in a field value.
+var buriedURLRe = regexp.MustCompile(`https?://[^\s{}\\]+`)
+
+func checkBuriedURLs(v *Validator, entry *BibEntry) []*ValidationError {
+	var errs []*ValidationError
+	for _, field := range sortedFields(entry) {
+		if strings.EqualFold(field, "url") || strings.EqualFold(field, "doi") {
+			continue
+		}
+		// Trailing punctuation is more likely part of the sentence.
+		if u := strings.TrimRight(buriedURLRe.FindString(entry.Fields[field].String()), ".,;:)"); u != "" {
+			msg := fmt.Sprintf("contains the URL %s, consider moving it to the url field", u)
+			errs = append(errs, fieldError(entry, field, SeverityWarning, msg))
+		}
+	}
+	return errs
+}
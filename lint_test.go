@@ -0,0 +1,139 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectMojibake(t *testing.T) {
+	for _, s := range []string{"CafÃ©", "Ã¼ber", "donâ€™t", "Â©2020"} {
+		if !DetectMojibake(s) {
+			t.Errorf("expected mojibake in %q", s)
+		}
+	}
+	for _, s := range []string{"Café", "über", "don’t", "©2020", "SÃO PAULO", "Ångström", "naïve — “quoted”", "plain ascii"} {
+		if DetectMojibake(s) {
+			t.Errorf("unexpected mojibake in %q", s)
+		}
+	}
+}
+
+func TestLintMojibake(t *testing.T) {
+	bib := MustParse(t, `
+@article{bad, title = {CafÃ© Society}, author = {Müller, J.}}
+@article{good, title = {Café Society}}
+`)
+	if errs := bib.Lint(LintOptions{}); len(errs) != 0 {
+		t.Errorf("lint should be off by default, got %v", errs)
+	}
+	errs := bib.Lint(LintOptions{Mojibake: true})
+	if len(errs) != 1 {
+		t.Fatalf("expected one warning, got %v", errs)
+	}
+	if e := errs[0]; e.Key != "bad" || e.Field != "title" || e.Severity != SeverityWarning {
+		t.Errorf("unexpected warning %v", e)
+	}
+}
+
+func TestLintUnprotectedCaps(t *testing.T) {
+	bib := MustParse(t, `
+@article{bad, title = {The DNA Helix}}
+@article{good, title = {The {DNA} Helix}}
+@inproceedings{cmd, title = {Typesetting with \LaTeX{} and A Single Letter}, booktitle = {Proc. of {IEEE} ICSE}}
+`)
+	if errs := bib.Lint(LintOptions{}); len(errs) != 0 {
+		t.Errorf("lint should be off by default, got %v", errs)
+	}
+	errs := bib.Lint(LintOptions{UnprotectedCaps: true})
+	if len(errs) != 2 {
+		t.Fatalf("expected two warnings, got %v", errs)
+	}
+	if e := errs[0]; e.Key != "bad" || e.Field != "title" || !strings.Contains(e.Message, "{DNA}") {
+		t.Errorf("unexpected warning %v", e)
+	}
+	if e := errs[1]; e.Key != "cmd" || e.Field != "booktitle" || !strings.Contains(e.Message, "{ICSE}") {
+		t.Errorf("unexpected warning %v", e)
+	}
+}
+
+func TestLintInlineMacros(t *testing.T) {
+	bib := MustParse(t, `
+@string{tse = "IEEE Transactions on Software Engineering"}
+@article{macro, journal = tse}
+@article{inline, journal = {IEEE Transactions on Software Engineering}}
+@article{other, journal = {Software: Practice and Experience}}
+`)
+	errs := bib.Lint(LintOptions{InlineMacros: true})
+	if len(errs) != 1 {
+		t.Fatalf("expected one warning, got %v", errs)
+	}
+	if e := errs[0]; e.Key != "inline" || e.Field != "journal" || !strings.Contains(e.Message, "@string tse") {
+		t.Errorf("unexpected warning %v", e)
+	}
+}
+
+func TestLintSwappedAuthorTitle(t *testing.T) {
+	bib := MustParse(t, `
+@article{swapped, author = {A study of the effects of caffeine on sleep}, title = {Smith, Jane}}
+@article{initials, author = {Notes on the theory of sorting}, title = {J. R. Smith}}
+@article{normal, author = {Jane Smith and Ludwig van der Berg}, title = {A Study of Caffeine}}
+@article{short, author = {Ludwig van Beethoven}, title = {Symphony Nine}}
+`)
+	errs := bib.Lint(LintOptions{SwappedAuthorTitle: true})
+	if len(errs) != 2 {
+		t.Fatalf("expected two warnings, got %v", errs)
+	}
+	for i, key := range []string{"swapped", "initials"} {
+		if e := errs[i]; e.Key != key || e.Field != "author" || e.Severity != SeverityWarning {
+			t.Errorf("unexpected warning %v", e)
+		}
+	}
+}
+
+func TestLintBuriedURLs(t *testing.T) {
+	bib := MustParse(t, `
+@misc{buried, title = {Page}, note = {Available at https://example.com/page, accessed 2020}}
+@misc{proper, title = {Page}, url = {https://example.com/page}, doi = {10.1000/182}}
+`)
+	errs := bib.Lint(LintOptions{BuriedURLs: true})
+	if len(errs) != 1 {
+		t.Fatalf("expected one warning, got %v", errs)
+	}
+	if e := errs[0]; e.Key != "buried" || e.Field != "note" || e.Message != "contains the URL https://example.com/page, consider moving it to the url field" {
+		t.Errorf("unexpected warning %v", e)
+	}
+}
+
+func TestDoubleBraces(t *testing.T) {
+	bib := MustParse(t, `
+@misc{double, title = {{Whole Thing}}}
+@misc{nested, title = {The {DNA} Helix}}
+@misc{parts, title = {{DNA} and {RNA}}}
+@misc{accent, title = {{\"U}ber}}
+@misc{author, author = {{World Health Organization}}}
+`)
+	// The parser drops braces in quoted values, so build one directly.
+	quoted, _ := NewEntry("misc", "quoted").SetValue("title", BibQuoted("{Whole Thing}")).Build()
+	bib.AddEntry(quoted)
+	errs := bib.Lint(LintOptions{DoubleBraces: true})
+	if len(errs) != 2 || errs[0].Key != "double" || errs[1].Key != "quoted" || errs[0].Field != "title" {
+		t.Fatalf("expected warnings for double and quoted, got %v", errs)
+	}
+	if n := bib.UnwrapDoubleBraces(); n != 2 {
+		t.Errorf("expected 2 fields unwrapped, got %d", n)
+	}
+	if got, ok := quoted.Fields["title"].(BibQuoted); !ok || got != "Whole Thing" {
+		t.Errorf("got quoted title %#v", quoted.Fields["title"])
+	}
+	expect := map[string]string{
+		"double": "Whole Thing",
+		"nested": "The {DNA} Helix",
+		"parts":  "{DNA} and {RNA}",
+		"accent": `{\"U}ber`,
+	}
+	for key, title := range expect {
+		if got := bib.ByKey(key).Fields["title"].String(); got != title {
+			t.Errorf("%s: got title %q, expected %q", key, got, title)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package bibtex
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Aliases returns the alternate citation keys of the entry, listed in its ids
+// field.
+func (entry *BibEntry) Aliases() []string {
+	value, ok := entry.Fields["ids"]
+	if !ok {
+		return nil
+	}
+	return splitKeys(value.String())
+}
+
+// hasKey reports whether the entry is cited by key, either as its cite name or
+// one of its aliases.
+func (entry *BibEntry) hasKey(key string) bool {
+	if entry.CiteName == key {
+		return true
+	}
+	for _, alias := range entry.Aliases() {
+		if alias == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ByKey returns the entry with the given citation key, or nil if there is
+// none. Primary keys take precedence over aliases.
+func (bib *BibTex) ByKey(key string) *BibEntry {
+	for _, entry := range bib.Entries {
+		if entry.CiteName == key {
+			return entry
+		}
+	}
+	for _, entry := range bib.Entries {
+		if entry.hasKey(key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// ByAuthor returns the entries with an author or editor of the given last
+// name. Names are compared ignoring case and accents, so Muller matches
+// M{\"u}ller and Müller. Corporate names in braces only match as a whole.
+func (bib *BibTex) ByAuthor(lastName string) []*BibEntry {
+	want := foldName(lastName)
+	var entries []*BibEntry
+	for _, entry := range bib.Entries {
+		if entry.hasContributor(want) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// hasContributor reports whether an author or editor of the entry has the
+// last name last, as folded by foldName.
+func (entry *BibEntry) hasContributor(last string) bool {
+	for _, field := range []string{"author", "editor"} {
+		for _, name := range entry.Names(field) {
+			if foldName(name.Last) == last {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// foldName returns s in plain lowercase ASCII, for comparing names.
+func foldName(s string) string {
+	return strings.ToLower(ASCIIFold(strings.TrimSpace(DecodeLaTeX(s))))
+}
+
+// Referrers returns the entries that refer to the entry with the given key
+// through their crossref field. References by alias are included.
+func (bib *BibTex) Referrers(key string) []*BibEntry {
+	target := bib.ByKey(key)
+	if target == nil {
+		return nil
+	}
+	var referrers []*BibEntry
+	for _, entry := range bib.Entries {
+		if ref, ok := entry.Fields["crossref"]; ok && target.hasKey(strings.TrimSpace(ref.String())) {
+			referrers = append(referrers, entry)
+		}
+	}
+	return referrers
+}
+
+// AliasCollision is an alias of one entry that is the primary key of another.
+type AliasCollision struct {
+	Alias string
+	Entry *BibEntry // Entry declaring the alias.
+	Other *BibEntry // Entry whose key is the alias.
+}
+
+// AliasCollisions returns all aliases that duplicate another entry's key.
+func (bib *BibTex) AliasCollisions() []AliasCollision {
+	keys := map[string]*BibEntry{}
+	for _, entry := range bib.Entries {
+		keys[entry.CiteName] = entry
+	}
+	var collisions []AliasCollision
+	for _, entry := range bib.Entries {
+		for _, alias := range entry.Aliases() {
+			if other, ok := keys[alias]; ok && other != entry {
+				collisions = append(collisions, AliasCollision{Alias: alias, Entry: entry, Other: other})
+			}
+		}
+	}
+	return collisions
+}
+
+// splitKeys splits a comma separated list of citation keys.
+func splitKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// refFields are the fields that hold references to other entries' keys. The
+// ids field holds alternative keys of the entry itself, and so is not one.
+var refFields = []string{"crossref", "xdata", "entryset"}
+
+// RenameEntry changes the key of the entry oldKey to newKey, and rewrites all
+// references to it in the crossref, xdata and entryset fields of other
+// entries. Returns the number of references updated.
+func (bib *BibTex) RenameEntry(oldKey, newKey string) (int, error) {
+	entry := bib.ByKey(oldKey)
+	if entry == nil || entry.CiteName != oldKey {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownKey, oldKey)
+	}
+	if bib.ByKey(newKey) != nil {
+		return 0, fmt.Errorf("%w: %s", ErrDuplicateKey, newKey)
+	}
+	entry.CiteName = newKey
+	return bib.rewriteRefs(entry, map[string]string{oldKey: newKey}), nil
+}
+
+// rewriteRefs renames keys in the reference fields of all entries other than
+// except, returning the number of references rewritten.
+func (bib *BibTex) rewriteRefs(except *BibEntry, rename map[string]string) int {
+	n := 0
+	for _, entry := range bib.Entries {
+		if entry == except {
+			continue
+		}
+		for _, field := range refFields {
+			value, ok := entry.Fields[field]
+			if !ok {
+				continue
+			}
+			s, changed := renameKeys(value.String(), rename)
+			if changed == 0 {
+				continue
+			}
+			n += changed
+			if _, ok := value.(BibQuoted); ok {
+				entry.Fields[field] = BibQuoted(s)
+			} else {
+				entry.Fields[field] = NewBibConst(s)
+			}
+		}
+	}
+	return n
+}
+
+// renameKeys renames the keys in a comma-separated list, keeping the
+// separators and spacing around them. Returns the new list and the number of
+// keys renamed.
+func renameKeys(s string, rename map[string]string) (string, int) {
+	parts := strings.Split(s, ",")
+	n := 0
+	for i, part := range parts {
+		key := strings.TrimSpace(part)
+		if newKey, ok := rename[key]; ok && key != "" {
+			parts[i] = strings.Replace(part, key, newKey, 1)
+			n++
+		}
+	}
+	return strings.Join(parts, ","), n
+}
+
+// SanitizeKeys makes every citation key a valid ASCII key, folding accented
+// letters with ASCIIFold and replacing other unsafe characters as
+// SanitizeKey does. A key that would clash with another is given a numeric
+// suffix. References in the crossref, xdata and entryset fields are updated to
+// match. Returns the mapping from old to new keys of the entries
+// that were renamed.
+func (bib *BibTex) SanitizeKeys() map[string]string {
+	taken := map[string]bool{}
+	for _, entry := range bib.Entries {
+		taken[entry.CiteName] = true
+	}
+	rename := map[string]string{}
+	for _, entry := range bib.Entries {
+		key := asciiKey(entry.CiteName)
+		if key == entry.CiteName {
+			continue
+		}
+		for i, base := 2, key; taken[key]; i++ {
+			key = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[key] = true
+		rename[entry.CiteName] = key
+		entry.CiteName = key
+	}
+	if len(rename) > 0 {
+		bib.rewriteRefs(nil, rename)
+	}
+	return rename
+}
+
+// asciiKey returns a valid citation key for s containing only ASCII
+// characters.
+func asciiKey(s string) string {
+	folded := strings.Map(func(ch rune) rune {
+		if ch >= utf8.RuneSelf {
+			return ' '
+		}
+		return ch
+	}, ASCIIFold(s))
+	return SanitizeKey(folded)
+}
@@ -0,0 +1,161 @@
+package bibtex
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAliasLookup(t *testing.T) {
+	bib, err := Parse(strings.NewReader(`
+@book{knuth1984,
+  title = {The TeXbook},
+  ids = {knuth84, texbook},
+}
+@inbook{chapter,
+  title = {Fonts},
+  crossref = {texbook},
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book := bib.ByKey("texbook")
+	if book == nil || book.CiteName != "knuth1984" {
+		t.Fatalf("alias lookup failed: %v", book)
+	}
+	if aliases := book.Aliases(); len(aliases) != 2 || aliases[0] != "knuth84" || aliases[1] != "texbook" {
+		t.Errorf("unexpected aliases %v", aliases)
+	}
+
+	referrers := bib.Referrers("knuth1984")
+	if len(referrers) != 1 || referrers[0].CiteName != "chapter" {
+		t.Errorf("unexpected referrers %v", referrers)
+	}
+
+	if collisions := bib.AliasCollisions(); len(collisions) != 0 {
+		t.Errorf("unexpected collisions %v", collisions)
+	}
+}
+
+func TestAliasCollision(t *testing.T) {
+	bib, err := Parse(strings.NewReader(`
+@book{a, ids = {b}}
+@book{b, title = {B}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bib.ByKey("b").CiteName != "b" {
+		t.Errorf("primary key should take precedence over alias")
+	}
+	collisions := bib.AliasCollisions()
+	if len(collisions) != 1 {
+		t.Fatalf("expected one collision, got %v", collisions)
+	}
+	if c := collisions[0]; c.Alias != "b" || c.Entry.CiteName != "a" || c.Other.CiteName != "b" {
+		t.Errorf("unexpected collision %+v", c)
+	}
+}
+
+func TestRenameEntry(t *testing.T) {
+	bib := MustParse(t, `
+@inproceedings{paper, crossref = {proc}, title = {Paper}}
+@inproceedings{other, crossref = {proc}, title = {Other}}
+@proceedings{proc, title = {Proceedings}}
+@misc{unrelated, crossref = {processing}}
+`)
+	n, err := bib.RenameEntry("proc", "proc2020")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 references updated, got %d", n)
+	}
+	if bib.ByKey("proc2020") == nil || bib.ByKey("proc") != nil {
+		t.Errorf("entry not renamed")
+	}
+	for _, key := range []string{"paper", "other"} {
+		if got := bib.ByKey(key).Fields["crossref"].String(); got != "proc2020" {
+			t.Errorf("%s: crossref not updated: %q", key, got)
+		}
+	}
+	if got := bib.ByKey("unrelated").Fields["crossref"].String(); got != "processing" {
+		t.Errorf("unrelated crossref changed: %q", got)
+	}
+}
+
+// Tests that the ids of other entries, which are their own alternative keys,
+// are left alone, and that separators are kept.
+func TestRenameEntryIDs(t *testing.T) {
+	bib := MustParse(t, `
+@book{book, title = {Book}}
+@misc{other, ids = {book, other2}}
+@incollection{part, entryset = "book ,  part2"}
+`)
+	n, err := bib.RenameEntry("book", "book2020")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 reference updated, got %d", n)
+	}
+	if got := bib.ByKey("other").Fields["ids"].String(); got != "book, other2" {
+		t.Errorf("ids changed: %q", got)
+	}
+	entryset := bib.ByKey("part").Fields["entryset"]
+	if _, ok := entryset.(BibQuoted); !ok || entryset.String() != "book2020 ,  part2" {
+		t.Errorf("got entryset %#v", entryset)
+	}
+}
+
+func TestRenameEntryErrors(t *testing.T) {
+	bib := MustParse(t, `@misc{a, title = {A}} @misc{b, title = {B}}`)
+	if _, err := bib.RenameEntry("a", "b"); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey, got %v", err)
+	}
+	if _, err := bib.RenameEntry("c", "d"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestByAuthor(t *testing.T) {
+	bib := MustParse(t, `
+@article{utf8, author = {Müller, Hans and Smith, J.}}
+@article{latex, author = {Anna M{\"u}ller}}
+@book{editor, editor = {K. Muller}}
+@misc{corporate, author = {{Muller Group}}}
+@misc{other, author = {Mueller, Karl}}
+`)
+	AssertOrder(t, bib.ByAuthor("Muller"), "utf8,latex,editor")
+	AssertOrder(t, bib.ByAuthor("MÜLLER"), "utf8,latex,editor")
+	AssertOrder(t, bib.ByAuthor("Muller Group"), "corporate")
+	AssertOrder(t, bib.ByAuthor("Group"), "")
+}
+
+func TestSanitizeKeys(t *testing.T) {
+	bib := MustParse(t, `
+@proceedings{Müller2020, title = {Proceedings}}
+@inproceedings{paper, crossref = {Müller2020}, entryset = {Gödel, other}}
+@misc{Gödel, title = {Incompleteness}}
+@misc{Godel, title = {Clash}}
+@misc{plain, title = {Plain}}
+`)
+	rename := bib.SanitizeKeys()
+	expect := map[string]string{"Müller2020": "Muller2020", "Gödel": "Godel-2"}
+	if !reflect.DeepEqual(rename, expect) {
+		t.Errorf("got mapping %v; expected %v", rename, expect)
+	}
+	if bib.ByKey("Muller2020") == nil || bib.ByKey("Godel-2") == nil || bib.ByKey("plain") == nil {
+		t.Errorf("entries not renamed")
+	}
+	paper := bib.ByKey("paper")
+	if got := paper.Fields["crossref"].String(); got != "Muller2020" {
+		t.Errorf("crossref not updated: %q", got)
+	}
+	if got := paper.Fields["entryset"].String(); got != "Godel-2, other" {
+		t.Errorf("entryset not updated: %q", got)
+	}
+}
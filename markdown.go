@@ -0,0 +1,94 @@
+package bibtex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// MarkdownOptions configures Markdown output.
+type MarkdownOptions struct {
+	// Numbered writes a numbered list rather than a bulleted one.
+	Numbered bool
+	// Template, if set, renders each list item in place of the default
+	// markup. It is executed with a *Citation whose text fields are escaped
+	// for Markdown.
+	Template *template.Template
+}
+
+// markdownEscaper escapes the characters that Markdown treats as markup.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// markdownURLEscaper escapes the characters that would end a Markdown link
+// target.
+var markdownURLEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+
+// ToMarkdown writes the bibliography as a Markdown list, with one item per
+// entry.
+func (bib *BibTex) ToMarkdown(w io.Writer, opts MarkdownOptions) error {
+	var buf bytes.Buffer
+	for i, entry := range bib.Entries {
+		if opts.Numbered {
+			fmt.Fprintf(&buf, "%d. ", i+1)
+		} else {
+			buf.WriteString("- ")
+		}
+		c := markdownCitation(NewCitation(entry))
+		if opts.Template != nil {
+			if err := opts.Template.Execute(&buf, c); err != nil {
+				return err
+			}
+		} else {
+			writeMarkdownCitation(&buf, c)
+		}
+		buf.WriteString("\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// markdownCitation returns a copy of c escaped for Markdown.
+func markdownCitation(c *Citation) *Citation {
+	return &Citation{
+		Key:     markdownEscaper.Replace(c.Key),
+		Type:    markdownEscaper.Replace(c.Type),
+		Authors: markdownEscaper.Replace(c.Authors),
+		Title:   markdownEscaper.Replace(c.Title),
+		Venue:   markdownEscaper.Replace(c.Venue),
+		Year:    markdownEscaper.Replace(c.Year),
+		DOI:     markdownEscaper.Replace(c.DOI),
+		URL:     markdownURLEscaper.Replace(c.URL),
+	}
+}
+
+// writeMarkdownCitation writes the default markup for an escaped citation.
+func writeMarkdownCitation(buf *bytes.Buffer, c *Citation) {
+	var parts []string
+	if c.Authors != "" {
+		parts = append(parts, sentence(c.Authors))
+	}
+	if c.Title != "" {
+		parts = append(parts, "*"+c.Title+"*"+sentence(c.Title)[len(c.Title):])
+	}
+	switch {
+	case c.Venue != "" && c.Year != "":
+		parts = append(parts, sentence(c.Venue+", "+c.Year))
+	case c.Venue != "":
+		parts = append(parts, sentence(c.Venue))
+	case c.Year != "":
+		parts = append(parts, sentence(c.Year))
+	}
+	if c.URL != "" {
+		text := "Link"
+		if c.DOI != "" {
+			text = "DOI"
+		}
+		parts = append(parts, fmt.Sprintf("[%s](%s)", text, c.URL))
+	}
+	buf.WriteString(strings.Join(parts, " "))
+}
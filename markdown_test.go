@@ -0,0 +1,40 @@
+package bibtex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestToMarkdown(t *testing.T) {
+	bib := MustParse(t, `@article{a,
+  author = {M{\"u}ller, J. and Smith, A.},
+  title = {Fish \& Chips: \textit{A} <Study> of *stars*},
+  journal = {J. Food},
+  year = 2020,
+  doi = {10.1000/182},
+}
+@misc{b, title = {Page}, url = {http://example.com/a (b)}}`)
+	var buf bytes.Buffer
+	if err := bib.ToMarkdown(&buf, MarkdownOptions{Numbered: true}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "1. Müller, J., Smith, A. *Fish & Chips: A \\<Study\\> of \\*stars\\**. J. Food, 2020. [DOI](https://doi.org/10.1000/182)\n" +
+		"2. *Page*. [Link](http://example.com/a%20%28b%29)\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("got\n%s\nexpected\n%s", got, expect)
+	}
+}
+
+func TestToMarkdownTemplate(t *testing.T) {
+	bib := MustParse(t, `@misc{my_key, title = {A_B}}`)
+	tmpl := template.Must(template.New("").Parse(`**{{.Title}}** [{{.Key}}]`))
+	var buf bytes.Buffer
+	if err := bib.ToMarkdown(&buf, MarkdownOptions{Template: tmpl}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, `- **A\_B** [my\_key]`) {
+		t.Errorf("unexpected output %s", got)
+	}
+}
@@ -0,0 +1,68 @@
+package bibtex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Merge combines bibliographies into one, as if their sources were
+// concatenated in the order given. Entries, preambles and warnings are
+// appended in order, and entries are shared rather than copied. Where more
+// than one input defines a string variable, the last definition wins, and a
+// warning wrapping ErrRedefinedStringVar is recorded if the values differ.
+// Entries keep the definitions they were parsed with.
+func Merge(bibs ...*BibTex) *BibTex {
+	merged := NewBibTex()
+	for _, bib := range bibs {
+		merged.Warnings = append(merged.Warnings, bib.Warnings...)
+		for _, key := range sortedStringVars(bib) {
+			def := bib.StringVar[key]
+			if prev, ok := merged.StringVar[key]; ok && prev.Value.RawString() != def.Value.RawString() {
+				merged.Warnings = append(merged.Warnings, fmt.Errorf("%w: %s", ErrRedefinedStringVar, def.Key))
+			}
+			merged.StringVar[key] = def
+		}
+		for _, p := range bib.Preambles {
+			merged.AddPreamble(p)
+		}
+		for _, entry := range bib.Entries {
+			merged.AddEntry(entry)
+		}
+	}
+	return merged
+}
+
+// StringVarOrder returns the keys of the string variables of bib in the order
+// they are written out: sorted, except that each follows the variables its
+// value refers to, so that every definition can be resolved when read back.
+func (bib *BibTex) StringVarOrder() []string {
+	var order []string
+	written := map[string]bool{}
+	var visit func(key string)
+	visit = func(key string) {
+		v, ok := bib.StringVar[key]
+		if !ok || written[key] {
+			return
+		}
+		written[key] = true
+		walkStringVars(v.Value, func(ref *BibVar) {
+			visit(strings.ToLower(ref.Key))
+		})
+		order = append(order, key)
+	}
+	for _, key := range sortedStringVars(bib) {
+		visit(key)
+	}
+	return order
+}
+
+// sortedStringVars returns the sorted keys of the string variables of bib.
+func sortedStringVars(bib *BibTex) []string {
+	keys := make([]string, 0, len(bib.StringVar))
+	for key := range bib.StringVar {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
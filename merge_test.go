@@ -0,0 +1,38 @@
+package bibtex
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := MustParse(t, `
+@string{pub = {ACM}}
+@string{conf = pub # { Conference}}
+@misc{a, publisher = pub}
+`)
+	b := MustParse(t, `
+@string{PUB = {IEEE}}
+@string{abbr = {IEEE}}
+@misc{b, publisher = pub}
+`)
+	for i := 0; i < 10; i++ {
+		merged := Merge(a, b)
+		if got := merged.GetStringVar("pub"); got == nil || got.String() != "IEEE" {
+			t.Fatalf("expected the last definition of pub to win, got %v", got)
+		}
+		if len(merged.Warnings) != 1 || !errors.Is(merged.Warnings[0], ErrRedefinedStringVar) {
+			t.Errorf("unexpected warnings %v", merged.Warnings)
+		}
+		if order := merged.StringVarOrder(); !reflect.DeepEqual(order, []string{"abbr", "pub", "conf"}) {
+			t.Errorf("got order %v", order)
+		}
+		if len(merged.Entries) != 2 || merged.Entries[0].CiteName != "a" || merged.Entries[1].CiteName != "b" {
+			t.Errorf("entries not merged in order")
+		}
+	}
+	if got := Merge(a, b).Entries[0].Fields["publisher"].String(); got != "ACM" {
+		t.Errorf("entry of first input resolved to %q", got)
+	}
+}
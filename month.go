@@ -0,0 +1,65 @@
+package bibtex
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonthFormat is an output format for the month field.
+type MonthFormat int
+
+const (
+	// MonthUnchanged leaves month values as they are.
+	MonthUnchanged MonthFormat = iota
+	// MonthMacro writes months as the standard macros, e.g. jul.
+	MonthMacro
+	// MonthLong writes months as full English names, e.g. {July}.
+	MonthLong
+	// MonthNumeric writes months as numbers, e.g. 7.
+	MonthNumeric
+)
+
+// monthMacros are the month string variables predefined by BibTeX styles.
+var monthMacros = [...]string{
+	"jan", "feb", "mar", "apr", "may", "jun",
+	"jul", "aug", "sep", "oct", "nov", "dec",
+}
+
+// Month returns the month of the entry, parsed from the month field. The field
+// name is matched ignoring case.
+func (entry *BibEntry) Month() (time.Month, bool) {
+	value, ok := entry.Get("month")
+	if !ok {
+		return 0, false
+	}
+	return parseMonth(value.String())
+}
+
+// parseMonth parses a month given as a number, a macro or an English name.
+func parseMonth(s string) (time.Month, bool) {
+	s = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s), "."))
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 12 {
+			return 0, false
+		}
+		return time.Month(n), true
+	}
+	for i, macro := range monthMacros {
+		m := time.Month(i + 1)
+		if s == macro || s == strings.ToLower(m.String()) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// monthVar returns the predefined string variable for a month macro.
+func monthVar(key string) (*BibVar, bool) {
+	for i, macro := range monthMacros {
+		if strings.ToLower(key) == macro {
+			return &BibVar{Key: macro, Value: NewBibConst(time.Month(i + 1).String())}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,205 @@
+package bibtex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Name is a personal name from an author or editor field, split into the
+// parts BibTeX recognises. Parts retain any LaTeX markup.
+type Name struct {
+	First string
+	Von   string
+	Last  string
+	Jr    string
+}
+
+// String formats the name in the unambiguous "von Last, Jr, First" form.
+func (n Name) String() string {
+	s := n.Last
+	if n.Von != "" {
+		s = n.Von + " " + s
+	}
+	if n.Jr != "" {
+		s += ", " + n.Jr
+	}
+	if n.First != "" {
+		s += ", " + n.First
+	}
+	return s
+}
+
+// Names returns the parsed names in a field of the entry, such as author or
+// editor.
+func (entry *BibEntry) Names(field string) []Name {
+	value, ok := entry.Fields[field]
+	if !ok {
+		return nil
+	}
+	return ParseNames(value.String())
+}
+
+// ParseNames splits a list of names separated by "and" and parses each one.
+// Names enclosed in braces, such as corporate authors, are not split.
+func ParseNames(s string) []Name {
+	var names []Name
+	for _, part := range splitNames(s) {
+		names = append(names, ParseName(part))
+	}
+	return names
+}
+
+// NormalizeNames canonicalizes the separators in a list of names, so that names
+// are separated by exactly " and ", in lowercase, and each name is trimmed with
+// single spaces between its words. Separators in other cases, such as "AND",
+// and cramped separators such as "A.and B" are recognised. Names enclosed in
+// braces are left intact.
+func NormalizeNames(s string) string {
+	return strings.Join(splitNames(s), " and ")
+}
+
+// splitNames splits a list of names on the word "and", in any case, outside
+// braces. A name ending in an initial may run into the separator, as in
+// "A.and B".
+func splitNames(s string) []string {
+	var names, current []string
+	for _, word := range splitDepth0(s, ' ', '\t', '\n', '\r') {
+		if word == "" {
+			continue
+		}
+		if n := len(word) - len(".and"); n > 0 && strings.EqualFold(word[n:], ".and") {
+			current = append(current, word[:n+1])
+			word = "and"
+		}
+		if strings.EqualFold(word, "and") {
+			if len(current) > 0 {
+				names = append(names, strings.Join(current, " "))
+			}
+			current = nil
+			continue
+		}
+		current = append(current, word)
+	}
+	if len(current) > 0 {
+		names = append(names, strings.Join(current, " "))
+	}
+	return names
+}
+
+// ParseName parses a single name in any of the forms "First von Last",
+// "von Last, First" or "von Last, Jr, First".
+func ParseName(s string) Name {
+	var parts [][]string
+	for _, part := range splitDepth0(s, ',') {
+		parts = append(parts, splitWords(part))
+	}
+
+	var n Name
+	switch len(parts) {
+	case 1:
+		words := parts[0]
+		if len(words) == 0 {
+			return n
+		}
+		// First is the words before the first lowercase word, von runs to
+		// the last lowercase word, and Last is the remainder. The last word
+		// is always part of Last.
+		last := len(words) - 1
+		vonStart, vonEnd := -1, -1
+		for i := 0; i < last; i++ {
+			if isLowerWord(words[i]) {
+				if vonStart < 0 {
+					vonStart = i
+				}
+				vonEnd = i + 1
+			}
+		}
+		if vonStart < 0 {
+			n.First = strings.Join(words[:last], " ")
+			n.Last = words[last]
+		} else {
+			n.First = strings.Join(words[:vonStart], " ")
+			n.Von = strings.Join(words[vonStart:vonEnd], " ")
+			n.Last = strings.Join(words[vonEnd:], " ")
+		}
+	default:
+		n.Von, n.Last = splitVonLast(parts[0])
+		if len(parts) == 2 {
+			n.First = strings.Join(parts[1], " ")
+		} else {
+			n.Jr = strings.Join(parts[1], " ")
+			n.First = strings.Join(parts[2], " ")
+		}
+	}
+	return n
+}
+
+// splitVonLast splits the "von Last" part of a name. The von part is the
+// leading lowercase words, always leaving at least one word for Last.
+func splitVonLast(words []string) (string, string) {
+	i := 0
+	for i < len(words)-1 && isLowerWord(words[i]) {
+		i++
+	}
+	return strings.Join(words[:i], " "), strings.Join(words[i:], " ")
+}
+
+// isLowerWord reports whether the first letter of word outside braces is
+// lowercase, marking it as part of the von particle. A brace group starting
+// with a command, such as {\"O}, is a special character and counts as the
+// letter it produces.
+func isLowerWord(word string) bool {
+	depth := 0
+	for i, ch := range word {
+		switch {
+		case ch == '{':
+			if depth == 0 && strings.HasPrefix(word[i:], "{\\") {
+				for _, letter := range DecodeLaTeX(word[i:]) {
+					if unicode.IsLetter(letter) {
+						return unicode.IsLower(letter)
+					}
+				}
+			}
+			depth++
+		case ch == '}':
+			depth--
+		case depth == 0 && unicode.IsLetter(ch):
+			return unicode.IsLower(ch)
+		}
+	}
+	return false
+}
+
+// splitWords splits s on whitespace outside braces.
+func splitWords(s string) []string {
+	var words []string
+	for _, word := range splitDepth0(s, ' ', '\t', '\n', '\r', '~') {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// splitDepth0 splits s on any of the separators that are not enclosed in
+// braces, trimming the space around each part.
+func splitDepth0(s string, seps ...rune) []string {
+	var parts []string
+	var buf strings.Builder
+	depth := 0
+	for _, ch := range s {
+		switch ch {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth == 0 && strings.ContainsRune(string(seps), ch) {
+			parts = append(parts, strings.TrimSpace(buf.String()))
+			buf.Reset()
+			continue
+		}
+		buf.WriteRune(ch)
+	}
+	return append(parts, strings.TrimSpace(buf.String()))
+}
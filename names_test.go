@@ -0,0 +1,94 @@
+package bibtex
+
+import "testing"
+
+func TestParseName(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Expect Name
+	}{
+		{"Donald E. Knuth", Name{First: "Donald E.", Last: "Knuth"}},
+		{"Knuth, Donald E.", Name{First: "Donald E.", Last: "Knuth"}},
+		{"Ludwig van Beethoven", Name{First: "Ludwig", Von: "van", Last: "Beethoven"}},
+		{"van Beethoven, Ludwig", Name{First: "Ludwig", Von: "van", Last: "Beethoven"}},
+		{"Jean de la Fontaine", Name{First: "Jean", Von: "de la", Last: "Fontaine"}},
+		{"King, Jr, Martin Luther", Name{First: "Martin Luther", Last: "King", Jr: "Jr"}},
+		{"{Barnes and Noble}", Name{Last: "{Barnes and Noble}"}},
+		{"M{\\\"u}ller, J{\\\"o}rg", Name{First: "J{\\\"o}rg", Last: "M{\\\"u}ller"}},
+		{"{\\\"O}zge Aks{\\i}n", Name{First: "{\\\"O}zge", Last: "Aks{\\i}n"}},
+		{"Plato", Name{Last: "Plato"}},
+	}
+	for _, c := range cases {
+		if got := ParseName(c.Input); got != c.Expect {
+			t.Errorf("ParseName(%q) = %#v; expected %#v", c.Input, got, c.Expect)
+		}
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	names := ParseNames("Doe, John and {Barnes and Noble} and Jane Roe")
+	if len(names) != 3 {
+		t.Fatalf("expected 3 names, got %v", names)
+	}
+	expect := []string{"Doe, John", "{Barnes and Noble}", "Roe, Jane"}
+	for i, n := range names {
+		if n.String() != expect[i] {
+			t.Errorf("name %d: got %q, expected %q", i, n.String(), expect[i])
+		}
+	}
+}
+
+func TestNormalizeNames(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Expect string
+	}{
+		{"Doe, J.and Roe, R.", "Doe, J. and Roe, R."},
+		{"  John  Doe  and\n  Jane   Roe ", "John Doe and Jane Roe"},
+		{"{Barnes  and  Noble} and D.~E. Knuth", "{Barnes  and  Noble} and D.~E. Knuth"},
+		{"Doe and and Roe", "Doe and Roe"},
+		{"A AND B And C", "A and B and C"},
+		{"Doe, J.AND Roe, R.", "Doe, J. and Roe, R."},
+		{"Anderson, J. and Brand, K.", "Anderson, J. and Brand, K."},
+	}
+	for _, c := range cases {
+		if got := NormalizeNames(c.Input); got != c.Expect {
+			t.Errorf("NormalizeNames(%q) = %q; expected %q", c.Input, got, c.Expect)
+		}
+	}
+}
+
+func TestParseNamesCase(t *testing.T) {
+	names := ParseNames("A AND B")
+	if len(names) != 2 || names[0].Last != "A" || names[1].Last != "B" {
+		t.Errorf("got names %v", names)
+	}
+	names = ParseNames("Anderson, J.")
+	if len(names) != 1 || names[0].Last != "Anderson" || names[0].First != "J." {
+		t.Errorf("got names %v", names)
+	}
+}
+
+func TestParseNamesCramped(t *testing.T) {
+	names := ParseNames("Doe, J.and Roe, R.")
+	if len(names) != 2 || names[0].Last != "Doe" || names[1].Last != "Roe" {
+		t.Errorf("got names %v", names)
+	}
+}
+
+func TestParseNamesLines(t *testing.T) {
+	entry := MustParse(t, "@article{a,\n  author = {\n    Doe, Jane and\n    Roe, Richard\n    and Smith,\n      John\n  },\n}").Entries[0]
+	names := entry.Names("author")
+	expect := []string{"Doe, Jane", "Roe, Richard", "Smith, John"}
+	if len(names) != len(expect) {
+		t.Fatalf("got names %v", names)
+	}
+	for i, n := range names {
+		if n.String() != expect[i] {
+			t.Errorf("name %d: got %q, expected %q", i, n.String(), expect[i])
+		}
+	}
+	if got := NormalizeNames(entry.Fields["author"].String()); got != "Doe, Jane and Roe, Richard and Smith, John" {
+		t.Errorf("got normalized names %q", got)
+	}
+}
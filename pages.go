@@ -0,0 +1,95 @@
+package bibtex
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// pageRangeRe matches page ranges separated by hyphens or dashes, with
+	// optional surrounding whitespace. Pages are either roman numerals or
+	// arabic numerals with an optional letter prefix, as in S1 for a
+	// supplement.
+	pageRangeRe = regexp.MustCompile(`^([A-Za-z]*\d+|[ivxlcdm]+|[IVXLCDM]+)\s*(?:-+|–|—)\s*([A-Za-z]*\d+|[ivxlcdm]+|[IVXLCDM]+)$`)
+
+	// pageRe matches a single page, which may also be an electronic article
+	// identifier such as e12345.
+	pageRe = regexp.MustCompile(`^(?:[A-Za-z]*\d+|[ivxlcdm]+|[IVXLCDM]+)$`)
+)
+
+// PageRange parses the pages field of the entry. Ranges may be written with
+// any number of hyphens or a unicode dash, optionally surrounded by spaces.
+// For a single page end is empty. The field name is matched ignoring case.
+func (entry *BibEntry) PageRange() (start, end string, ok bool) {
+	value, found := entry.Get("pages")
+	if !found {
+		return "", "", false
+	}
+	return parsePageRange(value.String())
+}
+
+func parsePageRange(s string) (start, end string, ok bool) {
+	s = strings.TrimSpace(s)
+	if m := pageRangeRe.FindStringSubmatch(s); m != nil {
+		return m[1], m[2], true
+	}
+	if pageRe.MatchString(s) {
+		return s, "", true
+	}
+	return "", "", false
+}
+
+// NormalizePages rewrites a page range in the canonical BibTeX form 1--10.
+// Values that are not recognised as a page range are returned unchanged.
+func NormalizePages(s string) string {
+	start, end, ok := parsePageRange(s)
+	if !ok || end == "" {
+		return s
+	}
+	return start + "--" + end
+}
+
+// Volume returns the volume field of the entry.
+func (entry *BibEntry) Volume() string {
+	return fieldString(entry, "volume")
+}
+
+// Number returns the number field of the entry.
+func (entry *BibEntry) Number() string {
+	return fieldString(entry, "number")
+}
+
+// Locator renders the volume, number and pages of the entry in the form
+// 12(3):45--67, omitting any parts that are missing.
+func (entry *BibEntry) Locator() string {
+	var buf strings.Builder
+	buf.WriteString(entry.Volume())
+	if number := entry.Number(); number != "" {
+		buf.WriteString("(" + number + ")")
+	}
+	if pages := fieldString(entry, "pages"); pages != "" {
+		if buf.Len() > 0 {
+			buf.WriteString(":")
+		}
+		buf.WriteString(NormalizePages(pages))
+	}
+	return buf.String()
+}
+
+// ChapterLocator renders the chapter and pages of the entry in the form
+// ch. 3, pp. 45--67, omitting any parts that are missing. A single page is
+// written as p. 45.
+func (entry *BibEntry) ChapterLocator() string {
+	var parts []string
+	if chapter := fieldString(entry, "chapter"); chapter != "" {
+		parts = append(parts, "ch. "+chapter)
+	}
+	if pages := fieldString(entry, "pages"); pages != "" {
+		if _, end, ok := parsePageRange(pages); ok && end == "" {
+			parts = append(parts, "p. "+pages)
+		} else {
+			parts = append(parts, "pp. "+NormalizePages(pages))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
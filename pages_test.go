@@ -0,0 +1,107 @@
+package bibtex
+
+import "testing"
+
+func TestPageRange(t *testing.T) {
+	cases := []struct {
+		Pages      string
+		Start, End string
+		OK         bool
+	}{
+		{"1-10", "1", "10", true},
+		{"1--10", "1", "10", true},
+		{"1–10", "1", "10", true},
+		{"1 - 10", "1", "10", true},
+		{"xi--xv", "xi", "xv", true},
+		{"42", "42", "", true},
+		{"e12345", "e12345", "", true},
+		{"S1-S10", "S1", "S10", true},
+		{"forthcoming", "", "", false},
+	}
+	for _, c := range cases {
+		entry := NewBibEntry("article", "a")
+		entry.AddField("pages", NewBibConst(c.Pages))
+		start, end, ok := entry.PageRange()
+		if start != c.Start || end != c.End || ok != c.OK {
+			t.Errorf("PageRange(%q) = %q, %q, %v; expected %q, %q, %v", c.Pages, start, end, ok, c.Start, c.End, c.OK)
+		}
+	}
+	entry := MustParse(t, `@article{a, Pages = {1-10}}`).Entries[0]
+	if start, end, ok := entry.PageRange(); start != "1" || end != "10" || !ok {
+		t.Errorf("Pages field: got %q, %q, %v", start, end, ok)
+	}
+}
+
+func TestNormalizePages(t *testing.T) {
+	cases := map[string]string{
+		"1-10":         "1--10",
+		"1--10":        "1--10",
+		"1–10":         "1--10",
+		"1 - 10":       "1--10",
+		"e12345":       "e12345",
+		"forthcoming":  "forthcoming",
+		"S1-S10":       "S1--S10",
+		"R1-R5":        "R1--R5",
+		"xi-xv":        "xi--xv",
+		"online-first": "online-first",
+		"see p-10":     "see p-10",
+		"10a-10c":      "10a-10c",
+	}
+	for pages, expected := range cases {
+		if got := NormalizePages(pages); got != expected {
+			t.Errorf("NormalizePages(%q) = %q; expected %q", pages, got, expected)
+		}
+	}
+}
+
+func TestFormatNormalizePages(t *testing.T) {
+	AssertFormat(t, &Formatter{NormalizePages: true}, "@article{a, pages = {1 - 10}}", "@article{a,\n    pages = \"1--10\",\n}\n")
+	AssertFormat(t, &Formatter{NormalizePages: true}, "@article{a, Pages = {1 - 10}}", "@article{a,\n    Pages = \"1--10\",\n}\n")
+}
+
+func TestLocator(t *testing.T) {
+	cases := []struct {
+		Src      string
+		Expected string
+	}{
+		{`@article{a, volume = 12, number = 3, pages = {45-67}}`, "12(3):45--67"},
+		{`@article{a, volume = 12, pages = {45--67}}`, "12:45--67"},
+		{`@article{a, pages = {45 - 67}}`, "45--67"},
+		{`@article{a, volume = 12, number = 3}`, "12(3)"},
+		{`@article{a, Volume = 12, Number = 3, Pages = {45-67}}`, "12(3):45--67"},
+		{`@article{a, title = {T}}`, ""},
+	}
+	for _, c := range cases {
+		entry := MustParse(t, c.Src).Entries[0]
+		if got := entry.Locator(); got != c.Expected {
+			t.Errorf("%s: got locator %q, expected %q", c.Src, got, c.Expected)
+		}
+	}
+}
+
+func TestChapterLocator(t *testing.T) {
+	cases := []struct {
+		Src      string
+		Expected string
+	}{
+		{`@inbook{a, chapter = 3, pages = {45-67}}`, "ch. 3, pp. 45--67"},
+		{`@inbook{a, chapter = 3}`, "ch. 3"},
+		{`@incollection{a, pages = {45--67}}`, "pp. 45--67"},
+		{`@incollection{a, pages = 45}`, "p. 45"},
+		{`@inbook{a, Chapter = 3, Pages = 45}`, "ch. 3, p. 45"},
+		{`@inbook{a, title = {T}}`, ""},
+	}
+	for _, c := range cases {
+		entry := MustParse(t, c.Src).Entries[0]
+		if got := entry.ChapterLocator(); got != c.Expected {
+			t.Errorf("%s: got chapter locator %q, expected %q", c.Src, got, c.Expected)
+		}
+	}
+}
+
+func TestVolumeNumber(t *testing.T) {
+	entry := MustParse(t, `@article{a, volume = { 12 }, number = "3"}`).Entries[0]
+	if entry.Volume() != "12" || entry.Number() != "3" {
+		t.Errorf("got volume %q, number %q", entry.Volume(), entry.Number())
+	}
+}
@@ -0,0 +1,104 @@
+package bibtex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Report is the combined result of the checks on a bibliography, grouped by
+// citation key. Issues that concern the bibliography as a whole, such as parse
+// warnings, have the empty key.
+type Report struct {
+	Keys   []string                      // Keys with issues, in entry order.
+	Issues map[string][]*ValidationError // Issues by key.
+}
+
+// Count returns the number of issues of the given severity.
+func (r Report) Count(severity Severity) int {
+	n := 0
+	for _, issues := range r.Issues {
+		for _, issue := range issues {
+			if issue.Severity == severity {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// add records an issue in the report.
+func (r *Report) add(issue *ValidationError) {
+	r.Issues[issue.Key] = append(r.Issues[issue.Key], issue)
+}
+
+// Report runs all checks over the bibliography: validation including required
+// fields, duplicate keys and aliases, references to undefined string
+// variables, every lint, and the warnings recorded by the parser.
+func (bib *BibTex) Report() Report {
+	r := Report{Issues: map[string][]*ValidationError{}}
+	for _, err := range bib.Warnings {
+		if errors.Is(err, ErrDuplicateKey) {
+			continue // Reported with the entry below.
+		}
+		r.add(&ValidationError{Severity: SeverityWarning, Message: err.Error()})
+	}
+
+	v := &Validator{RequiredFields: true}
+	lint := bib.Lint(LintOptions{Mojibake: true, UnprotectedCaps: true, InlineMacros: true, SwappedAuthorTitle: true, BuriedURLs: true, DoubleBraces: true})
+	seen := map[string]bool{}
+	for _, entry := range bib.Entries {
+		key := strings.ToLower(entry.CiteName)
+		if seen[key] {
+			r.add(&ValidationError{Key: entry.CiteName, Value: entry.CiteName, Severity: SeverityError, Message: ErrDuplicateKey.Error()})
+		}
+		seen[key] = true
+		for _, issue := range v.ValidateEntry(entry) {
+			r.add(issue)
+		}
+		for _, issue := range bib.checkMacros(entry) {
+			r.add(issue)
+		}
+	}
+	for _, c := range bib.AliasCollisions() {
+		msg := fmt.Sprintf("alias is the key of entry %s", c.Other.CiteName)
+		r.add(&ValidationError{Key: c.Entry.CiteName, Field: "ids", Value: c.Alias, Severity: SeverityError, Message: msg})
+	}
+	for _, issue := range lint {
+		r.add(issue)
+	}
+
+	// List the keys with issues in entry order, after bibliography issues.
+	keys := []string{""}
+	for _, entry := range bib.Entries {
+		keys = append(keys, entry.CiteName)
+	}
+	listed := map[string]bool{}
+	for _, key := range keys {
+		if _, ok := r.Issues[key]; ok && !listed[key] {
+			listed[key] = true
+			r.Keys = append(r.Keys, key)
+		}
+	}
+	return r
+}
+
+// checkMacros reports fields of entry that use string variables not defined in
+// bib.
+func (bib *BibTex) checkMacros(entry *BibEntry) []*ValidationError {
+	var errs []*ValidationError
+	for _, field := range sortedFields(entry) {
+		walkStringVars(entry.Fields[field], func(v *BibVar) {
+			if bib.GetStringVar(v.Key) == nil {
+				errs = append(errs, &ValidationError{
+					Key:      entry.CiteName,
+					Field:    field,
+					Value:    v.Key,
+					Severity: SeverityError,
+					Message:  ErrUnresolvedMacro.Error(),
+				})
+			}
+		})
+	}
+	return errs
+}
@@ -0,0 +1,42 @@
+package bibtex
+
+import "testing"
+
+func TestReport(t *testing.T) {
+	bib := MustParse(t, `
+@string{j = {Journal}}
+@string{j = {Journal of Things}}
+@article{ok, author = {Doe, Jane}, title = {Fine}, journal = j, year = 2020}
+@article{bad, author = {Doe, Jane}, title = {The DNA Helix}, year = {20x0}, doi = {nope}}
+@misc{Ok, title = {Duplicate}, isbn = {123}}
+@misc{dup, title = {Field}, title = {Twice}, ids = {ok}}
+`)
+	other := NewBibEntry("misc", "moved")
+	other.AddField("note", &BibVar{Key: "elsewhere", Value: NewBibConst("Other")})
+	bib.AddEntry(other)
+
+	r := bib.Report()
+	// Errors: bad is missing its journal and has a malformed doi; Ok has a
+	// duplicate key and malformed isbn; dup has an alias colliding with ok;
+	// moved uses an undefined macro.
+	if n := r.Count(SeverityError); n != 6 {
+		t.Errorf("got %d errors, expected 6: %v", n, r.Issues)
+	}
+	// Warnings: the redefined string and duplicate field from the parser,
+	// and the implausible year and unprotected DNA in the title of bad.
+	if n := r.Count(SeverityWarning); n != 4 {
+		t.Errorf("got %d warnings, expected 4: %v", n, r.Issues)
+	}
+	expect := []string{"", "bad", "Ok", "dup", "moved"}
+	if len(r.Keys) != len(expect) {
+		t.Fatalf("got keys %q, expected %q", r.Keys, expect)
+	}
+	for i, key := range expect {
+		if r.Keys[i] != key {
+			t.Errorf("got keys %q, expected %q", r.Keys, expect)
+		}
+	}
+	if len(r.Issues["ok"]) != 0 {
+		t.Errorf("unexpected issues for ok: %v", r.Issues["ok"])
+	}
+}
@@ -0,0 +1,94 @@
+package bibtex
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidType is an error for an entry type that is empty or contains
+// characters other than letters and digits.
+var ErrInvalidType = errors.New("Invalid entry type")
+
+// requiredFields are the fields that standard BibTeX styles require for each
+// entry type. Each requirement lists alternative fields, any one of which
+// satisfies it, including the biblatex equivalents.
+var requiredFields = map[string][][]string{
+	"article":       {{"author"}, {"title"}, {"journal", "journaltitle"}, {"year", "date"}},
+	"book":          {{"author", "editor"}, {"title"}, {"publisher"}, {"year", "date"}},
+	"booklet":       {{"title"}},
+	"inbook":        {{"author", "editor"}, {"title"}, {"chapter", "pages"}, {"publisher"}, {"year", "date"}},
+	"incollection":  {{"author"}, {"title"}, {"booktitle"}, {"publisher"}, {"year", "date"}},
+	"inproceedings": {{"author"}, {"title"}, {"booktitle"}, {"year", "date"}},
+	"conference":    {{"author"}, {"title"}, {"booktitle"}, {"year", "date"}},
+	"manual":        {{"title"}},
+	"mastersthesis": {{"author"}, {"title"}, {"school", "institution"}, {"year", "date"}},
+	"online":        {{"author", "editor"}, {"title"}, {"url"}, {"year", "date"}},
+	"phdthesis":     {{"author"}, {"title"}, {"school", "institution"}, {"year", "date"}},
+	"proceedings":   {{"title"}, {"year", "date"}},
+	"techreport":    {{"author"}, {"title"}, {"institution"}, {"year", "date"}},
+	"unpublished":   {{"author"}, {"title"}, {"note"}},
+}
+
+// ValidationErrors is a list of validation issues, usable as an error.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func checkRequired(v *Validator, entry *BibEntry) []*ValidationError {
+	if !v.RequiredFields {
+		return nil
+	}
+	var errs []*ValidationError
+	for _, alternatives := range requiredFields[canonicalType(entry.Type)] {
+		if !hasAnyField(entry, alternatives) {
+			msg := "missing required field " + strings.Join(alternatives, " or ")
+			errs = append(errs, &ValidationError{
+				Key:      entry.CiteName,
+				Field:    alternatives[0],
+				Severity: SeverityError,
+				Message:  msg,
+			})
+		}
+	}
+	return errs
+}
+
+// hasAnyField reports whether entry has a non-empty value for any of fields.
+func hasAnyField(entry *BibEntry, fields []string) bool {
+	for _, field := range fields {
+		if value, ok := entry.Get(field); ok && strings.TrimSpace(value.String()) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetType changes the type of the entry, normalized to lowercase without
+// spaces as NewBibEntry does, with aliases such as www resolved to their
+// canonical type as the parser does. It returns ErrInvalidType if the type is
+// not made of letters and digits, in which case the entry is unchanged. If v
+// is not nil, the required fields of the new type are then checked as v
+// specifies, and any missing are returned as ValidationErrors.
+func (entry *BibEntry) SetType(t string, v *Validator) error {
+	t = strings.ToLower(strings.Replace(t, " ", "", -1))
+	if t == "" || strings.IndexFunc(t, func(ch rune) bool { return !isAlphanum(ch) }) >= 0 {
+		return ErrInvalidType
+	}
+	entry.Type, entry.Alias, entry.RawType = t, "", t
+	if canonical := canonicalType(t); canonical != t {
+		entry.Type, entry.Alias = canonical, t
+	}
+	if v == nil {
+		return nil
+	}
+	if errs := checkRequired(v, entry); len(errs) > 0 {
+		return ValidationErrors(errs)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package bibtex
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetType(t *testing.T) {
+	entry := MustParse(t, `@misc{a, author = {A. Author}, title = {T}, year = 2020}`).Entries[0]
+	v := &Validator{RequiredFields: true}
+	err := entry.SetType("Article", v)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	if len(errs) != 1 || errs[0].Field != "journal" || errs[0].Severity != SeverityError {
+		t.Errorf("unexpected validation errors %v", errs)
+	}
+	if entry.Type != "article" {
+		t.Errorf("type %q, expected article", entry.Type)
+	}
+
+	entry.AddField("journal", NewBibConst("J"))
+	if err := entry.SetType("article", v); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := entry.SetType("not a {type}", v); err != ErrInvalidType {
+		t.Errorf("got %v, expected ErrInvalidType", err)
+	}
+	if entry.Type != "article" {
+		t.Errorf("invalid type changed entry type to %q", entry.Type)
+	}
+}
+
+func TestSetTypeAlias(t *testing.T) {
+	entry := MustParse(t, `@Misc{a, title = {T}}`).Entries[0]
+	if err := entry.SetType("WWW", nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if entry.Type != "online" || entry.Alias != "www" || entry.RawType != "www" {
+		t.Errorf("got type %q, alias %q, raw type %q", entry.Type, entry.Alias, entry.RawType)
+	}
+	if err := entry.SetType("electronic", &Validator{RequiredFields: true}); err == nil {
+		t.Errorf("expected missing fields of online")
+	}
+	if entry.Type != "online" || entry.Alias != "electronic" {
+		t.Errorf("got type %q, alias %q", entry.Type, entry.Alias)
+	}
+}
+
+func TestValidateRequiredFields(t *testing.T) {
+	entry := MustParse(t, `@book{a, editor = {E. Editor}, title = {T}, date = 2020}`).Entries[0]
+	if errs := (&Validator{}).ValidateEntry(entry); len(errs) != 0 {
+		t.Errorf("unexpected validation errors without RequiredFields: %v", errs)
+	}
+	errs := (&Validator{RequiredFields: true}).ValidateEntry(entry)
+	if len(errs) != 1 || errs[0].Field != "publisher" {
+		t.Errorf("unexpected validation errors %v", errs)
+	}
+}
+
+func TestValidateRequiredFieldsTechReport(t *testing.T) {
+	v := &Validator{RequiredFields: true}
+	bib := MustParse(t, `
+@techreport{complete, author = {A. Author}, title = {T}, institution = {MIT}, year = 2020, number = {TR-1}}
+@techreport{missing, author = {A. Author}, title = {T}, year = 2020}
+`)
+	if errs := v.ValidateEntry(bib.Entries[0]); len(errs) != 0 {
+		t.Errorf("unexpected validation errors %v", errs)
+	}
+	errs := v.ValidateEntry(bib.Entries[1])
+	if len(errs) != 1 || errs[0].Field != "institution" || errs[0].Message != "missing required field institution" {
+		t.Errorf("unexpected validation errors %v", errs)
+	}
+}
+
+func TestValidateRequiredFieldsLessCommonTypes(t *testing.T) {
+	v := &Validator{RequiredFields: true}
+	cases := map[string]string{
+		`@booklet{a, author = {A. Author}}`:          "title",
+		`@manual{a, organization = {ACME}}`:          "title",
+		`@unpublished{a, author = {A}, title = {T}}`: "note",
+		`@proceedings{a, title = {Proc.}}`:           "year",
+	}
+	for src, field := range cases {
+		errs := v.ValidateEntry(MustParse(t, src).Entries[0])
+		if len(errs) != 1 || errs[0].Field != field {
+			t.Errorf("%s: expected missing %s, got %v", src, field, errs)
+		}
+	}
+	for _, src := range []string{
+		`@booklet{a, title = {T}}`,
+		`@manual{a, title = {T}}`,
+		`@unpublished{a, author = {A}, title = {T}, note = {Draft}}`,
+		`@proceedings{a, title = {Proc.}, year = 2020}`,
+	} {
+		if errs := v.ValidateEntry(MustParse(t, src).Entries[0]); len(errs) != 0 {
+			t.Errorf("%s: unexpected validation errors %v", src, errs)
+		}
+	}
+}
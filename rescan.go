@@ -0,0 +1,71 @@
+package bibtex
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotSeekable is returned when rescanning a reader that cannot seek.
+var ErrNotSeekable = errors.New("bibtex: scanner input is not seekable")
+
+// checkpoint is a point at which the scanner state is known to be reset: the
+// @ sign at the start of an entry.
+type checkpoint struct {
+	offset int // Byte offset of the @ sign.
+	char   int // Position of the @ sign on its line.
+	lines  int // Number of complete lines before the @ sign.
+}
+
+// checkpoint records the last read rune as a safe point to rescan from.
+func (s *Scanner) checkpoint() {
+	cp := checkpoint{offset: s.start, char: s.pos.Char - 1, lines: len(s.pos.Lines)}
+	for n := len(s.checkpoints); n > 0 && s.checkpoints[n-1].offset >= cp.offset; n-- {
+		s.checkpoints = s.checkpoints[:n-1]
+	}
+	s.checkpoints = append(s.checkpoints, cp)
+}
+
+// RescanFrom prepares the scanner to re-scan its input after it was edited at
+// the given byte offset. The input is read again through the reader the
+// scanner was created with, which must implement io.Seeker and should now
+// return the edited text (for example a bytes.Reader after Reset).
+//
+// Scanning resumes from the safe point: the start of the entry containing
+// offset, that is the last @ sign scanned at or before it. Text before the
+// safe point is assumed unchanged by the edit, so token positions before it
+// remain valid. The next call to Scan returns the ATSIGN token at the safe
+// point, and Offset reports its position.
+//
+// Tokens after the edit resynchronise with the previous token stream at the
+// first ATSIGN token beyond the edited range: from there on the tokens are
+// identical with offsets shifted by the change in length of the edited text,
+// and callers may stop rescanning.
+func (s *Scanner) RescanFrom(offset int) error {
+	seeker, ok := s.src.(io.Seeker)
+	if !ok {
+		return ErrNotSeekable
+	}
+
+	// Find the safe point, discarding checkpoints after it.
+	cp := checkpoint{}
+	for n := len(s.checkpoints); n > 0; n-- {
+		if s.checkpoints[n-1].offset <= offset {
+			cp = s.checkpoints[n-1]
+			break
+		}
+		s.checkpoints = s.checkpoints[:n-1]
+	}
+	if len(s.checkpoints) > 0 {
+		s.checkpoints = s.checkpoints[:len(s.checkpoints)-1]
+	}
+
+	if _, err := seeker.Seek(int64(cp.offset), io.SeekStart); err != nil {
+		return err
+	}
+	s.r.Reset(s.src)
+	s.offset, s.size, s.start = cp.offset, 0, cp.offset
+	s.pos.Char = cp.char
+	s.pos.Lines = s.pos.Lines[:cp.lines]
+	s.parseField = false
+	return nil
+}
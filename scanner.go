@@ -3,32 +3,90 @@ package bibtex
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
-	"log"
 	"strconv"
 	"strings"
 )
 
-var parseField bool
+// Logger receives diagnostic messages. It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
 
 // Scanner is a lexical scanner
 type Scanner struct {
-	r   *bufio.Reader
-	pos TokenPos
+	Logger Logger // Destination for diagnostic messages, discarded if nil.
+
+	// MaxValueLength, if positive, is the most runes a braced or quoted value
+	// may have. A longer value is an ErrValueTooLong error at its start, which
+	// catches a missing closing brace before it swallows the rest of the
+	// input.
+	MaxValueLength int
+
+	src    io.Reader
+	r      *bufio.Reader
+	pos    TokenPos
+	offset int // Byte offset of the next rune.
+	size   int // Byte size of the last rune read.
+	start  int // Byte offset of the start of the last token.
+
+	startPos TokenPos // Position of the start of the last token.
+
+	parseField bool     // Scanning a field value.
+	err        error    // Error for the last ILLEGAL token, if known.
+	quoted     bool     // Last token was a quoted string.
+	comments   []string // Comment lines since the last token.
+	leading    []string // Comment lines directly above the last @.
+
+	checkpoints []checkpoint // Safe points to rescan from.
+	stats       ScanStats
 }
 
+// ScanStats are counts of the work done by a Scanner.
+type ScanStats struct {
+	Runes  int // Runes consumed.
+	Bytes  int // Bytes consumed.
+	Tokens int // Tokens returned, excluding the end of input.
+}
+
+// maxBufferSize limits the read buffer of a scanner over input of known size.
+const maxBufferSize = 64 << 10
+
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r), pos: TokenPos{Char: 0, Lines: []int{}}}
+	return newScanner(r, bufio.NewReader(r))
+}
+
+// NewScannerSize returns a new instance of Scanner whose read buffer has at
+// least the given size.
+func NewScannerSize(r io.Reader, size int) *Scanner {
+	return newScanner(r, bufio.NewReaderSize(r, size))
+}
+
+func newScanner(src io.Reader, r *bufio.Reader) *Scanner {
+	return &Scanner{src: src, r: r, pos: TokenPos{Char: 0, Lines: []int{}}}
+}
+
+// logf writes a diagnostic message to the logger, if any.
+func (s *Scanner) logf(format string, v ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, v...)
+	}
 }
 
 // read reads the next rune from the buffered reader.
 // Returns the rune(0) if an error occurs (or io.eof is returned).
 func (s *Scanner) read() rune {
-	ch, _, err := s.r.ReadRune()
+	ch, size, err := s.r.ReadRune()
 	if err != nil {
+		s.size = 0
 		return eof
 	}
+	s.offset += size
+	s.size = size
+	s.stats.Runes++
+	s.stats.Bytes += size
 	if ch == '\n' {
 		s.pos.Lines = append(s.pos.Lines, s.pos.Char)
 		s.pos.Char = 0
@@ -40,7 +98,14 @@ func (s *Scanner) read() rune {
 
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
+	if s.size == 0 {
+		return
+	}
 	_ = s.r.UnreadRune()
+	s.offset -= s.size
+	s.stats.Runes--
+	s.stats.Bytes -= s.size
+	s.size = 0
 	if s.pos.Char == 0 {
 		s.pos.Char = s.pos.Lines[len(s.pos.Lines)-1]
 		s.pos.Lines = s.pos.Lines[:len(s.pos.Lines)-1]
@@ -49,14 +114,46 @@ func (s *Scanner) unread() {
 	}
 }
 
+// Stats returns counts of the work done by the scanner so far.
+func (s *Scanner) Stats() ScanStats {
+	return s.stats
+}
+
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan() (tok Token, lit string) {
+	tok, lit = s.scan()
+	if tok != 0 || lit != "" { // Not the end of input.
+		s.stats.Tokens++
+	}
+	return tok, lit
+}
+
+func (s *Scanner) scan() (tok Token, lit string) {
+	s.err, s.quoted = nil, false
+	lines := len(s.pos.Lines)
 	ch := s.read()
-	if isWhitespace(ch) {
-		s.ignoreWhitespace()
+	for isWhitespace(ch) || ch == '%' {
+		if ch == '%' {
+			s.comments = append(s.comments, s.scanComment())
+		} else {
+			s.ignoreWhitespace()
+			if len(s.pos.Lines) > lines {
+				// A comment line ends with its newline, so any further newline
+				// is a blank line, separating the comments from what follows.
+				s.comments = nil
+			}
+		}
+		lines = len(s.pos.Lines)
 		ch = s.read()
 	}
-	if isAlphanum(ch) {
+	s.start = s.offset - s.size
+	s.startPos = s.lastPos()
+	s.leading = nil
+	if ch == '@' {
+		s.leading = s.comments
+	}
+	s.comments = nil
+	if isIdentStart(ch) {
 		s.unread()
 		return s.scanIdent()
 	}
@@ -64,27 +161,33 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 	case eof:
 		return 0, ""
 	case '@':
+		s.checkpoint()
 		return ATSIGN, string(ch)
 	case ':':
 		return COLON, string(ch)
 	case ',':
-		parseField = false // reset parseField if reached end of field.
+		s.parseField = false // reset parseField if reached end of field.
 		return COMMA, string(ch)
 	case '=':
-		parseField = true // set parseField if = sign outside quoted or ident.
+		s.parseField = true // set parseField if = sign outside quoted or ident.
 		return EQUAL, string(ch)
 	case '"':
 		return s.scanQuoted()
 	case '{':
-		if parseField {
+		if s.parseField {
 			return s.scanBraced()
 		}
 		return LBRACE, string(ch)
 	case '}':
-		if parseField { // reset parseField if reached end of entry.
-			parseField = false
+		if s.parseField { // reset parseField if reached end of entry.
+			s.parseField = false
 		}
 		return RBRACE, string(ch)
+	case '(':
+		return LPAREN, string(ch)
+	case ')':
+		s.parseField = false // reset parseField if reached end of entry.
+		return RPAREN, string(ch)
 	case '#':
 		return POUND, string(ch)
 	case ' ':
@@ -93,6 +196,65 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 	return ILLEGAL, string(ch)
 }
 
+// ScanAll scans to the end of input, returning the tokens and their literal
+// values in parallel slices. Scanning continues past ILLEGAL tokens, which are
+// included, and the error for the first of them is returned.
+func (s *Scanner) ScanAll() ([]Token, []string, error) {
+	var toks []Token
+	var lits []string
+	var first error
+	for {
+		tok, lit := s.Scan()
+		if tok == ILLEGAL && lit == "" && s.err == nil { // End of input.
+			return toks, lits, first
+		}
+		if tok == ILLEGAL && first == nil {
+			first = s.err
+			if first == nil {
+				first = &ErrParse{Pos: s.startPos, Err: fmt.Sprintf("%s: %q", ErrUnexpectedToken, lit), Kind: ErrUnexpectedToken}
+			}
+		}
+		toks = append(toks, tok)
+		lits = append(lits, lit)
+	}
+}
+
+// DumpTokens scans r and writes each token to w on a line of its own, as
+// NAME("literal") @line:col, for debugging. It returns the first error
+// writing, or else the error for the first ILLEGAL token.
+func DumpTokens(r io.Reader, w io.Writer) error {
+	s := NewScanner(r)
+	var first error
+	for {
+		tok, lit := s.Scan()
+		if tok == ILLEGAL && lit == "" && s.err == nil { // End of input.
+			return first
+		}
+		if tok == ILLEGAL && first == nil {
+			first = s.err
+		}
+		if _, err := fmt.Fprintf(w, "%s(%q) @%s\n", tok, lit, s.startPos); err != nil {
+			return err
+		}
+	}
+}
+
+// Err returns the error that caused the last ILLEGAL token, if known.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// lastPos returns the position of the last rune read.
+func (s *Scanner) lastPos() TokenPos {
+	n := len(s.pos.Lines)
+	return TokenPos{Char: s.pos.Char, Lines: s.pos.Lines[:n:n]}
+}
+
+// Offset returns the byte offset of the start of the last scanned token.
+func (s *Scanner) Offset() int {
+	return s.start
+}
+
 // scanIdent categorises a string to one of three categories.
 func (s *Scanner) scanIdent() (tok Token, lit string) {
 	switch ch := s.read(); ch {
@@ -111,7 +273,7 @@ func (s *Scanner) scanBare() (Token, string) {
 	for {
 		if ch := s.read(); ch == eof {
 			break
-		} else if !isAlphanum(ch) && !isBareSymbol(ch) || isWhitespace(ch) {
+		} else if !isAlphanum(ch) && !isBareLetter(ch) && !isBareSymbol(ch) || isWhitespace(ch) {
 			s.unread()
 			break
 		} else {
@@ -125,7 +287,7 @@ func (s *Scanner) scanBare() (Token, string) {
 		return PREAMBLE, str
 	} else if strings.ToLower(str) == "string" {
 		return STRING, str
-	} else if _, err := strconv.Atoi(str); err == nil && parseField { // Special case for numeric
+	} else if _, err := strconv.Atoi(str); err == nil && s.parseField { // Special case for numeric
 		return IDENT, str
 	}
 	return BAREIDENT, str
@@ -134,63 +296,154 @@ func (s *Scanner) scanBare() (Token, string) {
 // scanBraced parses a braced string, like {this}.
 func (s *Scanner) scanBraced() (Token, string) {
 	var buf bytes.Buffer
-	var macro bool
+	start := s.lastPos()
 	brace := 1
-	for {
+	for n := 1; ; n++ {
 		if ch := s.read(); ch == eof {
 			break
+		} else if s.tooLong(n, start) {
+			return ILLEGAL, buf.String()
 		} else if ch == '\\' {
 			_, _ = buf.WriteRune(ch)
-			macro = true
+			n += s.scanEscaped(&buf)
 		} else if ch == '{' {
 			_, _ = buf.WriteRune(ch)
 			brace++
 		} else if ch == '}' {
 			brace--
-			macro = false
 			if brace == 0 { // Balances open brace.
 				return IDENT, buf.String()
 			}
 			_, _ = buf.WriteRune(ch)
-		} else if ch == '@' {
-			if macro {
-				_, _ = buf.WriteRune(ch)
-			} else {
-				log.Fatalf("%s: %s", ErrUnexpectedAtsign, buf.String())
-			}
-		} else if isWhitespace(ch) {
-			_, _ = buf.WriteRune(ch)
-			macro = false
 		} else {
-			_, _ = buf.WriteRune(ch)
+			_, _ = buf.WriteRune(ch) // Including @, as in email addresses.
 		}
 	}
+	s.err = &ErrParse{Pos: start, Err: fmt.Sprintf("%s in braced string", ErrUnbalancedBrace), Kind: ErrUnbalancedBrace}
 	return ILLEGAL, buf.String()
 }
 
+// tooLong reports whether the nth rune read after the opening delimiter of the
+// value at start, which may be its closing delimiter, puts the value beyond
+// MaxValueLength, recording the error if so.
+func (s *Scanner) tooLong(n int, start TokenPos) bool {
+	if s.MaxValueLength <= 0 || n <= s.MaxValueLength+1 {
+		return false
+	}
+	s.err = &ErrParse{Pos: start, Err: fmt.Sprintf("%s: more than %d characters", ErrValueTooLong, s.MaxValueLength), Kind: ErrValueTooLong}
+	return true
+}
+
+// scanEscaped writes the rune following a backslash literally, so that escaped
+// delimiters such as \{ and \" do not end or nest a value. Returns the number
+// of runes consumed.
+func (s *Scanner) scanEscaped(buf *bytes.Buffer) int {
+	if ch := s.read(); isEscapable(ch) {
+		_, _ = buf.WriteRune(ch)
+		return 1
+	}
+	s.unread()
+	return 0
+}
+
 // scanQuoted parses a quoted string, like "this".
 func (s *Scanner) scanQuoted() (Token, string) {
 	var buf bytes.Buffer
+	start := s.lastPos()
 	brace := 0
-	for {
+	nested := false // Seen a quote inside braces.
+	for n := 1; ; n++ {
 		if ch := s.read(); ch == eof {
 			break
+		} else if s.tooLong(n, start) {
+			return ILLEGAL, buf.String()
+		} else if ch == '\\' {
+			_, _ = buf.WriteRune(ch)
+			n += s.scanEscaped(&buf)
 		} else if ch == '{' {
 			brace++
 		} else if ch == '}' {
 			brace--
 		} else if ch == '"' {
 			if brace == 0 { // Matches open quote, unescaped
+				s.quoted = true
 				return IDENT, buf.String()
 			}
 			_, _ = buf.WriteRune(ch)
+			nested = true
 		} else {
 			_, _ = buf.WriteRune(ch)
 		}
 	}
+	// A closing quote swallowed by an open brace is the likely culprit, even if
+	// later braces restore the balance.
+	if brace != 0 || nested {
+		s.err = &ErrParse{Pos: start, Err: fmt.Sprintf("%s in quoted string", ErrUnbalancedBrace), Kind: ErrUnbalancedBrace}
+	} else {
+		s.err = &ErrParse{Pos: start, Err: ErrUnterminatedString.Error(), Kind: ErrUnterminatedString}
+	}
 	return ILLEGAL, buf.String()
 }
 
+// skipEntry consumes the body of an entry, from its opening delimiter to the
+// matching close, without tokenising it. Returns false if the input ends
+// before the entry is closed.
+func (s *Scanner) skipEntry() bool {
+	s.ignoreWhitespace()
+	open := s.read()
+	if open != '{' && open != '(' {
+		s.unread()
+		return true
+	}
+	braces, parens := 0, 0
+	quoted := false
+	for {
+		switch s.read() {
+		case eof:
+			return false
+		case '\\':
+			s.read() // Escaped delimiters do not count.
+		case '"':
+			if braces == 0 {
+				quoted = !quoted
+			}
+		case '%':
+			if braces == 0 && !quoted { // A comment between fields.
+				s.scanComment()
+			}
+		case '{':
+			braces++
+		case '}':
+			braces--
+		case '(':
+			if braces == 0 && !quoted { // Parentheses in values do not count.
+				parens++
+			}
+		case ')':
+			if braces == 0 && !quoted {
+				parens--
+			}
+		}
+		if open == '{' && braces < 0 || open == '(' && braces == 0 && parens < 0 {
+			return true
+		}
+	}
+}
+
+// scanComment consumes a % line comment up to and including the newline, and
+// returns its text.
+func (s *Scanner) scanComment() string {
+	var buf bytes.Buffer
+	for {
+		if ch := s.read(); ch == eof || ch == '\n' {
+			break
+		} else {
+			_, _ = buf.WriteRune(ch)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
 // ignoreWhitespace consumes the current rune and all contiguous whitespace.
 func (s *Scanner) ignoreWhitespace() {
 	for {
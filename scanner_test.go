@@ -0,0 +1,324 @@
+package bibtex
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scanned is a token returned by the scanner with its offset and position.
+type scanned struct {
+	Tok    Token
+	Lit    string
+	Offset int
+	Pos    string
+}
+
+// scanAll scans until EOF.
+func scanAll(s *Scanner) []scanned {
+	var toks []scanned
+	for {
+		tok, lit := s.Scan()
+		if tok == 0 {
+			return toks
+		}
+		toks = append(toks, scanned{tok, lit, s.Offset(), s.pos.String()})
+	}
+}
+
+func TestRescanFrom(t *testing.T) {
+	before := "@article{a,\n  title = {First}\n}\n\n@article{b,\n  title = {Second}\n}\n\n@misc{c, note = {Third}}\n"
+	after := strings.Replace(before, "Second", "Second Edition", 1)
+	edit := strings.Index(before, "Second")
+
+	r := bytes.NewReader([]byte(before))
+	s := NewScanner(r)
+	scanAll(s)
+
+	r.Reset([]byte(after))
+	if err := s.RescanFrom(edit); err != nil {
+		t.Fatal(err)
+	}
+	rescanned := scanAll(s)
+
+	// Rescanning resumes from the start of the edited entry.
+	if len(rescanned) == 0 || rescanned[0].Tok != ATSIGN || rescanned[0].Offset != strings.Index(after, "@article{b") {
+		t.Fatalf("rescan did not resume at the edited entry: %v", rescanned)
+	}
+
+	// The rescanned tokens match the tail of a full scan of the edited text.
+	full := scanAll(NewScanner(strings.NewReader(after)))
+	tail := full[len(full)-len(rescanned):]
+	for i := range rescanned {
+		if rescanned[i] != tail[i] {
+			t.Errorf("token %d: rescanned %v, expected %v", i, rescanned[i], tail[i])
+		}
+	}
+}
+
+func TestRescanFromNotSeekable(t *testing.T) {
+	s := NewScanner(bytes.NewBufferString("@misc{a, title={A}}"))
+	scanAll(s)
+	if err := s.RescanFrom(0); err != ErrNotSeekable {
+		t.Errorf("expected ErrNotSeekable, got %v", err)
+	}
+}
+
+// Tests that values longer than the read buffer scan correctly for any buffer
+// size.
+func TestNewScannerSize(t *testing.T) {
+	value := strings.Repeat("long {value} é ", 1000)
+	src := "@misc{a, note = {" + value + "}}"
+	for _, size := range []int{16, 100, 4096, 1 << 16} {
+		toks := scanAll(NewScannerSize(strings.NewReader(src), size))
+		if len(toks) != 9 {
+			t.Fatalf("size %d: expected 9 tokens, got %d", size, len(toks))
+		}
+		if tok := toks[7]; tok.Tok != IDENT || tok.Lit != value {
+			t.Errorf("size %d: value scanned incorrectly", size)
+		}
+	}
+}
+
+func TestScanEscapedDelimiters(t *testing.T) {
+	cases := []struct {
+		Src   string
+		Value string
+	}{
+		{`@misc{a, note = {a \{ b \} c}}`, `a \{ b \} c`},
+		{`@misc{a, note = {a \} b}}`, `a \} b`},
+		{`@misc{a, note = "a \# b"}`, `a \# b`},
+		{`@misc{a, note = "say \"hi\""}`, `say \"hi\"`},
+		{`@misc{a, note = "a \{ b"}`, `a \{ b`},
+		{`@misc{a, note = {\\}}`, `\\`},
+		{`@misc{a, note = {x\\{y}}}`, `x\\{y}`},
+	}
+	for _, c := range cases {
+		bib := MustParse(t, c.Src)
+		if got := bib.Entries[0].Fields["note"].String(); got != c.Value {
+			t.Errorf("%s: got %q, expected %q", c.Src, got, c.Value)
+		}
+	}
+}
+
+func TestScanQuotedUnbalancedBrace(t *testing.T) {
+	src := "@misc{a,\n  title = \"text {with unclosed brace\" next,\n}\n"
+	_, err := Parse(strings.NewReader(src))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expect := "Parse failed at 2:11: Unbalanced brace in quoted string"
+	if err.Error() != expect {
+		t.Errorf("got error %q, expected %q", err, expect)
+	}
+}
+
+func TestScanQuotedUnbalancedBraceEOF(t *testing.T) {
+	_, err := Parse(strings.NewReader(`@misc{a, title = "{open`))
+	var perr *ErrParse
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if !strings.Contains(perr.Err, ErrUnbalancedBrace.Error()) {
+		t.Errorf("got error %q, expected unbalanced brace", perr.Err)
+	}
+}
+
+func TestScanNoTrailingNewline(t *testing.T) {
+	for _, src := range []string{`@misc{a, title = {T}}`, `@misc{a, year = 2020}`, `@misc{a, title = "T"}`} {
+		bib, err := Parse(strings.NewReader(src))
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if len(bib.Entries) != 1 || len(bib.Entries[0].Fields) != 1 {
+			t.Errorf("%s: unexpected entries %v", src, bib.Entries)
+		}
+	}
+}
+
+func TestScanBracedUnbalancedBraceEOF(t *testing.T) {
+	_, err := Parse(strings.NewReader("@misc{a,\n  title = {open {nested}"))
+	if !errors.Is(err, ErrUnbalancedBrace) {
+		t.Fatalf("got error %v, expected ErrUnbalancedBrace", err)
+	}
+	expect := "Parse failed at 2:11: Unbalanced brace in braced string"
+	if err.Error() != expect {
+		t.Errorf("got error %q, expected %q", err, expect)
+	}
+}
+
+func TestScannerStats(t *testing.T) {
+	src := "@misc{a, title = {Café}}\n"
+	s := NewScanner(strings.NewReader(src))
+	scanAll(s)
+	expect := ScanStats{Runes: len([]rune(src)), Bytes: len(src), Tokens: 9}
+	if got := s.Stats(); got != expect {
+		t.Errorf("got stats %+v, expected %+v", got, expect)
+	}
+}
+
+// Tests that % comment lines between and inside entries are skipped by the
+// scanner, leaving the same tokens as the source without them.
+func TestScanComments(t *testing.T) {
+	src := `% Leading comment
+@misc{a, % after the key
+  % on its own line
+  note = {50% in a value} % after a value
+  # "x", % after a concatenation
+% before the close
+}
+% between entries
+@misc{b, title = "T"}
+% at the end`
+	plain := `
+@misc{a,
+  note = {50% in a value}
+  # "x",
+}
+@misc{b, title = "T"}
+`
+	var got, expect []string
+	for _, tok := range scanAll(NewScanner(strings.NewReader(src))) {
+		got = append(got, tok.Tok.String()+" "+tok.Lit)
+	}
+	for _, tok := range scanAll(NewScanner(strings.NewReader(plain))) {
+		expect = append(expect, tok.Tok.String()+" "+tok.Lit)
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got tokens\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expect, "\n"))
+	}
+
+	bib := MustParse(t, src)
+	AssertOrder(t, bib.Entries, "a,b")
+	if got := bib.Entries[0].Fields["note"].String(); got != "50% in a valuex" {
+		t.Errorf("got note %q", got)
+	}
+}
+
+// Tests that escaped percent signs in values are kept, while a % outside a
+// value starts a comment.
+func TestScanEscapedPercent(t *testing.T) {
+	src := `% A comment with {braces} and @misc{fake, title = {F}}
+@misc{a,
+  note = {50\% off}, % trailing comment
+  title = "100\% {sure}",
+}
+`
+	bib := MustParse(t, src)
+	if len(bib.Entries) != 1 {
+		t.Fatalf("got entries %v", bib.Entries)
+	}
+	entry := bib.Entries[0]
+	if got := entry.Fields["note"].String(); got != `50\% off` {
+		t.Errorf("got note %q", got)
+	}
+	if got := entry.Fields["title"].String(); got != `100\% sure` {
+		t.Errorf("got title %q", got)
+	}
+	if got := DecodeLaTeX(entry.Fields["note"].String()); got != "50% off" {
+		t.Errorf("got decoded note %q", got)
+	}
+
+	// Skipped entries follow the same rules.
+	skipped := `@book{b, note = "50% off", % unbalanced } in comment
+  title = {100\% {sure}}}
+` + src
+	bib, err := (&Parser{Types: []string{"misc"}}).Parse(strings.NewReader(skipped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bib.Entries) != 1 || bib.Entries[0].CiteName != "a" {
+		t.Errorf("got entries %v", bib.Entries)
+	}
+}
+
+func TestScanAll(t *testing.T) {
+	src := "@article{a,\n  title = {T} # \"U\",\n  year = 2020\n}\n"
+	var expect []scanned
+	for _, tok := range scanAll(NewScanner(strings.NewReader(src))) {
+		expect = append(expect, scanned{Tok: tok.Tok, Lit: tok.Lit})
+	}
+	toks, lits, err := NewScanner(strings.NewReader(src)).ScanAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toks) != len(expect) || len(lits) != len(expect) {
+		t.Fatalf("got %d tokens, expected %d", len(toks), len(expect))
+	}
+	for i := range expect {
+		if toks[i] != expect[i].Tok || lits[i] != expect[i].Lit {
+			t.Errorf("token %d: got %v %q, expected %v %q", i, toks[i], lits[i], expect[i].Tok, expect[i].Lit)
+		}
+	}
+}
+
+func TestScanAllError(t *testing.T) {
+	toks, lits, err := NewScanner(strings.NewReader("@misc{a} $ @misc{b}")).ScanAll()
+	if !errors.Is(err, ErrUnexpectedToken) || !strings.Contains(strings.Join(lits, ""), "$") {
+		t.Errorf("got error %v, expected %v", err, ErrUnexpectedToken)
+	}
+	if n := len(toks); n == 0 || toks[n-1] != RBRACE {
+		t.Errorf("scanning stopped early: %v", toks)
+	}
+
+	_, _, err = NewScanner(strings.NewReader("@misc{a, note = {open")).ScanAll()
+	if !errors.Is(err, ErrUnbalancedBrace) {
+		t.Errorf("got error %v, expected %v", err, ErrUnbalancedBrace)
+	}
+}
+
+func TestScanAllUnterminatedQuote(t *testing.T) {
+	for _, src := range []string{`@misc{a, title = "`, `@misc{a, title = "open`} {
+		_, _, err := NewScanner(strings.NewReader(src)).ScanAll()
+		if !errors.Is(err, ErrUnterminatedString) {
+			t.Errorf("%s: got error %v, expected %v", src, err, ErrUnterminatedString)
+		}
+		if err := DumpTokens(strings.NewReader(src), ioutil.Discard); !errors.Is(err, ErrUnterminatedString) {
+			t.Errorf("%s: DumpTokens got error %v, expected %v", src, err, ErrUnterminatedString)
+		}
+		if _, err := Parse(strings.NewReader(src)); !errors.Is(err, ErrUnterminatedString) {
+			t.Errorf("%s: Parse got error %v, expected %v", src, err, ErrUnterminatedString)
+		}
+	}
+}
+
+func TestScanBracedAtsign(t *testing.T) {
+	toks := scanAll(NewScanner(strings.NewReader(`@misc{a, author = {John Doe <john@example.com>}}`)))
+	if len(toks) != 9 || toks[7].Tok != IDENT || toks[7].Lit != "John Doe <john@example.com>" {
+		t.Errorf("unexpected tokens %v", toks)
+	}
+}
+
+func TestDumpTokens(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DumpTokens(strings.NewReader("@misc{a,\n  title = {T}}"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	var names []string
+	for _, line := range lines {
+		names = append(names, line[:strings.Index(line, "(")])
+	}
+	if got := strings.Join(names, " "); got != "ATSIGN BAREIDENT LBRACE BAREIDENT COMMA BAREIDENT EQUAL IDENT RBRACE" {
+		t.Errorf("got tokens %s", got)
+	}
+	if lines[5] != `BAREIDENT("title") @2:3` {
+		t.Errorf("got line %q", lines[5])
+	}
+}
+
+func TestScanPoundInValue(t *testing.T) {
+	toks := scanAll(NewScanner(strings.NewReader(`@misc{a, title = {C# programming} # "F# too" # x}`)))
+	var got []string
+	for _, tok := range toks[7:] {
+		got = append(got, tok.Tok.String()+":"+tok.Lit)
+	}
+	expect := "IDENT:C# programming POUND:# IDENT:F# too POUND:# BAREIDENT:x RBRACE:}"
+	if strings.Join(got, " ") != expect {
+		t.Errorf("got tokens %s, expected %s", strings.Join(got, " "), expect)
+	}
+}
@@ -0,0 +1,22 @@
+package bibtex
+
+import "strings"
+
+// searchFields are the fields included in SearchText, in order.
+var searchFields = []string{"author", "editor", "title", "journal", "journaltitle", "booktitle", "keywords"}
+
+// SearchText returns the authors, editors, title, venue and keywords of the
+// entry as plain lowercase text for search indexing. LaTeX is decoded,
+// formatting commands are removed, accents are folded to ASCII where possible
+// and whitespace is collapsed.
+func (entry *BibEntry) SearchText() string {
+	var parts []string
+	for _, field := range searchFields {
+		if value, ok := entry.Get(field); ok {
+			if text := collapseSpace(ASCIIFold(DecodeLaTeX(value.String()))); text != "" {
+				parts = append(parts, strings.ToLower(text))
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
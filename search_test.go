@@ -0,0 +1,18 @@
+package bibtex
+
+import "testing"
+
+func TestSearchText(t *testing.T) {
+	entry := MustParse(t, `@inproceedings{a,
+  author = {M{\"u}ller, J{\"o}rg and {\'E}mile Zola},
+  title = {\textbf{Fast} \& \emph{Furious}: 50\% Faster {DNA}   Sequencing},
+  booktitle = {Proc. of the {ACM}},
+  keywords = {genomics, Speed},
+  year = 2020,
+  note = {Not indexed},
+}`).Entries[0]
+	expect := "muller, jorg and emile zola fast & furious: 50% faster dna sequencing proc. of the acm genomics, speed"
+	if got := entry.SearchText(); got != expect {
+		t.Errorf("got %q, expected %q", got, expect)
+	}
+}
@@ -0,0 +1,129 @@
+package bibtex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DuplicateGroup is a set of entries that appear to describe the same work,
+// in entry order.
+type DuplicateGroup struct {
+	Entries []*BibEntry
+}
+
+// SimilarTitles groups entries whose titles are alike. Titles are compared
+// after decoding LaTeX, folding to lowercase ASCII and removing punctuation,
+// by the ratio of their edit distance to the length of the longer title. Two
+// entries are alike if their similarity, between 0 and 1, is at least
+// threshold, and groups are formed by chains of alike entries. Entries with no
+// title are ignored. Groups are ordered by their first entry.
+func (bib *BibTex) SimilarTitles(threshold float64) []DuplicateGroup {
+	var entries []*BibEntry
+	var titles [][]rune
+	for _, entry := range bib.Entries {
+		if title := normalizeTitle(fieldString(entry, "title")); title != "" {
+			entries = append(entries, entry)
+			titles = append(titles, []rune(title))
+		}
+	}
+
+	// Join alike entries, with each pointing towards the first of its group.
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			if similarity(titles[i], titles[j]) < threshold {
+				continue
+			}
+			if ri, rj := root(i), root(j); ri < rj {
+				parent[rj] = ri
+			} else if rj < ri {
+				parent[ri] = rj
+			}
+		}
+	}
+
+	var groups []DuplicateGroup
+	index := map[int]int{} // Group index by root.
+	members := make([]int, len(entries))
+	for i := range entries {
+		members[root(i)]++
+	}
+	for i, entry := range entries {
+		r := root(i)
+		if members[r] < 2 {
+			continue
+		}
+		g, ok := index[r]
+		if !ok {
+			g = len(groups)
+			index[r] = g
+			groups = append(groups, DuplicateGroup{})
+		}
+		groups[g].Entries = append(groups[g].Entries, entry)
+	}
+	return groups
+}
+
+// normalizeTitle returns the words of a title in plain lowercase ASCII,
+// separated by single spaces.
+func normalizeTitle(s string) string {
+	words := strings.FieldsFunc(foldName(s), func(ch rune) bool {
+		return !unicode.IsLetter(ch) && !unicode.IsDigit(ch)
+	})
+	return strings.Join(words, " ")
+}
+
+// similarity returns one minus the Levenshtein distance between a and b as a
+// fraction of the length of the longer.
+func similarity(a, b []rune) float64 {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(n)
+}
+
+// levenshtein returns the number of single character insertions, deletions
+// and substitutions needed to change a into b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
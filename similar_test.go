@@ -0,0 +1,40 @@
+package bibtex
+
+import "testing"
+
+func TestSimilarTitles(t *testing.T) {
+	bib := MustParse(t, `
+@article{a, title = {Attention Is All You Need}}
+@misc{b, title = {Quantum Error Correction}}
+@inproceedings{c, title = {{A}ttention is all you need.}}
+@misc{d, title = {Attention is all you needs}}
+@misc{e, title = {Deep Residual Learning}}
+@misc{f,}
+`)
+	groups := bib.SimilarTitles(0.9)
+	if len(groups) != 1 {
+		t.Fatalf("expected one group, got %d", len(groups))
+	}
+	AssertOrder(t, groups[0].Entries, "a,c,d")
+
+	if groups := bib.SimilarTitles(1); len(groups) != 1 || len(groups[0].Entries) != 2 {
+		t.Errorf("expected exact matches a and c only, got %v", groups)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		A, B     string
+		Distance int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+	}
+	for _, c := range cases {
+		if got := levenshtein([]rune(c.A), []rune(c.B)); got != c.Distance {
+			t.Errorf("levenshtein(%q, %q) = %d; expected %d", c.A, c.B, got, c.Distance)
+		}
+	}
+}
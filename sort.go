@@ -0,0 +1,82 @@
+package bibtex
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SortKey is an order for the entries of a bibliography.
+type SortKey int
+
+const (
+	// ByAuthorYear sorts alphabetically by the last names of the authors, or
+	// editors if there are no authors, then by year.
+	ByAuthorYear SortKey = iota
+	// ByYearDesc sorts by year, most recent first. Entries without a numeric
+	// year come last.
+	ByYearDesc
+	// ByKey sorts by citation key.
+	ByKey
+	// ByType sorts by entry type.
+	ByType
+)
+
+// Sorted returns the entries of the bibliography in the given order. Ties are
+// broken by citation key. The bibliography itself is not modified.
+func (bib *BibTex) Sorted(by SortKey) []*BibEntry {
+	entries := append([]*BibEntry(nil), bib.Entries...)
+	keys := make(map[*BibEntry]string, len(entries))
+	years := make(map[*BibEntry]int, len(entries))
+	for _, entry := range entries {
+		keys[entry] = authorSortKey(entry)
+		years[entry] = sortYear(entry)
+	}
+	less := func(a, b *BibEntry) bool {
+		switch by {
+		case ByAuthorYear:
+			if keys[a] != keys[b] {
+				return keys[a] < keys[b]
+			}
+			if years[a] != years[b] {
+				return years[a] < years[b]
+			}
+		case ByYearDesc:
+			if years[a] != years[b] {
+				return years[a] > years[b]
+			}
+		case ByType:
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+		}
+		return a.CiteName < b.CiteName
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i], entries[j])
+	})
+	return entries
+}
+
+// authorSortKey returns a key ordering entry by the names of its authors, last
+// name first. LaTeX markup and accents are removed.
+func authorSortKey(entry *BibEntry) string {
+	names := entry.Names("author")
+	if len(names) == 0 {
+		names = entry.Names("editor")
+	}
+	var parts []string
+	for _, n := range names {
+		parts = append(parts, n.Last, n.First, n.Jr)
+	}
+	return strings.ToLower(ASCIIFold(DecodeLaTeX(strings.Join(parts, "\x00"))))
+}
+
+// sortYear returns the numeric year of entry, or -1 if it has none.
+func sortYear(entry *BibEntry) int {
+	year, err := strconv.Atoi(plainField(entry, "year"))
+	if err != nil {
+		return -1
+	}
+	return year
+}
@@ -0,0 +1,40 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+const sortSrc = `
+@article{c, author = {Zed, Anna}, year = 2001}
+@book{b, author = {{\"O}berg, Per and Zed, Anna}, year = 2010}
+@article{a, author = {Jan Oberg}, year = 2010}
+@misc{d, editor = {Adams, Ed}, year = {n.d.}}
+@article{e, author = {Zed, Anna}, year = 1999}
+`
+
+// AssertOrder checks the citation keys of entries, in order.
+func AssertOrder(t *testing.T, entries []*BibEntry, expect string) {
+	t.Helper()
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.CiteName)
+	}
+	if got := strings.Join(keys, ","); got != expect {
+		t.Errorf("got order %s, expected %s", got, expect)
+	}
+}
+
+func TestSortedByAuthorYear(t *testing.T) {
+	bib := MustParse(t, sortSrc)
+	AssertOrder(t, bib.Sorted(ByAuthorYear), "d,a,b,e,c")
+	AssertOrder(t, bib.Entries, "c,b,a,d,e")
+}
+
+func TestSortedByYearDesc(t *testing.T) {
+	AssertOrder(t, MustParse(t, sortSrc).Sorted(ByYearDesc), "a,b,c,e,d")
+}
+
+func TestSortedByType(t *testing.T) {
+	AssertOrder(t, MustParse(t, sortSrc).Sorted(ByType), "a,c,e,b,d")
+}
@@ -0,0 +1,112 @@
+package bibtex
+
+import (
+	"sort"
+	"strings"
+)
+
+// Subset returns a new BibTex containing the given entries, together with the
+// crossref and xdata parents they depend on and the string variables they
+// reference. Preambles are carried over. Entries are shared with b, not
+// copied; parents are placed after the selected entries, as BibTeX requires.
+func (bib *BibTex) Subset(entries []*BibEntry) *BibTex {
+	sub := NewBibTex()
+	included := map[*BibEntry]bool{}
+	for _, entry := range entries {
+		if !included[entry] {
+			included[entry] = true
+			sub.AddEntry(entry)
+		}
+	}
+
+	// Add parents, which may have parents of their own.
+	for i := 0; i < len(sub.Entries); i++ {
+		for _, key := range parentKeys(sub.Entries[i]) {
+			if parent := bib.ByKey(key); parent != nil && !included[parent] {
+				included[parent] = true
+				sub.AddEntry(parent)
+			}
+		}
+	}
+
+	// Add referenced string variables.
+	for _, p := range bib.Preambles {
+		sub.AddPreamble(p)
+		bib.addStringVarRefs(sub, p)
+	}
+	for _, entry := range sub.Entries {
+		for _, value := range entry.Fields {
+			bib.addStringVarRefs(sub, value)
+		}
+	}
+	return sub
+}
+
+// SplitByType returns a sub-bibliography for each entry type in bib, keyed by
+// type. Each is built by Subset, so carries the parents and string variables
+// its entries need; parents of another type appear in both bibliographies.
+func (bib *BibTex) SplitByType() map[string]*BibTex {
+	byType := map[string][]*BibEntry{}
+	for _, entry := range bib.Entries {
+		byType[entry.Type] = append(byType[entry.Type], entry)
+	}
+	split := make(map[string]*BibTex, len(byType))
+	for typ, entries := range byType {
+		split[typ] = bib.Subset(entries)
+	}
+	return split
+}
+
+// UnusedStrings returns the sorted names of the string variables that are not
+// referenced by any entry or preamble, directly or through other variables.
+func (bib *BibTex) UnusedStrings() []string {
+	used := bib.Subset(bib.Entries).StringVar
+	var unused []string
+	for key, def := range bib.StringVar {
+		if _, ok := used[key]; !ok {
+			unused = append(unused, def.Key)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// addStringVarRefs adds the string variables defined in bib and referenced by
+// s, directly or through other variables, to sub.
+func (bib *BibTex) addStringVarRefs(sub *BibTex, s BibString) {
+	walkStringVars(s, func(v *BibVar) {
+		key := strings.ToLower(v.Key)
+		if _, ok := sub.StringVar[key]; ok {
+			return
+		}
+		if def, ok := bib.StringVar[key]; ok {
+			sub.StringVar[key] = def
+			bib.addStringVarRefs(sub, def.Value)
+		}
+	})
+}
+
+// walkStringVars calls fn for each string variable referenced in s.
+func walkStringVars(s BibString, fn func(*BibVar)) {
+	switch s := s.(type) {
+	case *BibVar:
+		fn(s)
+	case *BibComposite:
+		for _, part := range *s {
+			walkStringVars(part, fn)
+		}
+	}
+}
+
+// parentKeys returns the keys of the entries referenced by the crossref and
+// xdata fields of entry.
+func parentKeys(entry *BibEntry) []string {
+	var keys []string
+	if ref, ok := entry.Fields["crossref"]; ok {
+		keys = append(keys, strings.TrimSpace(ref.String()))
+	}
+	if xdata, ok := entry.Fields["xdata"]; ok {
+		keys = append(keys, splitKeys(xdata.String())...)
+	}
+	return keys
+}
@@ -0,0 +1,114 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubset(t *testing.T) {
+	bib, err := Parse(strings.NewReader(`
+@string{ieee = {IEEE Transactions}}
+@string{acm = {ACM Computing Surveys}}
+@string{cup = {Cambridge University Press}}
+
+@article{a, journal = ieee, title = {A}}
+@article{b, journal = acm, title = {B}}
+@book{c, publisher = cup, title = {C}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := bib.Subset([]*BibEntry{bib.ByKey("a")})
+	if len(sub.Entries) != 1 || sub.Entries[0].CiteName != "a" {
+		t.Fatalf("unexpected entries %v", sub.Entries)
+	}
+	if len(sub.StringVar) != 1 || sub.StringVar["ieee"] == nil {
+		t.Errorf("expected only the ieee macro, got %v", sub.StringVar)
+	}
+}
+
+func TestSubsetParents(t *testing.T) {
+	bib, err := Parse(strings.NewReader(`
+@string{pub = {Publisher}}
+@inproceedings{paper, crossref = {proc}, title = {Paper}}
+@proceedings{proc, publisher = pub, xdata = {series}, title = {Proceedings}}
+@xdata{series, series = {LNCS}}
+@misc{other, title = {Other}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := bib.Subset([]*BibEntry{bib.ByKey("paper")})
+	var keys []string
+	for _, entry := range sub.Entries {
+		keys = append(keys, entry.CiteName)
+	}
+	if got := strings.Join(keys, ","); got != "paper,proc,series" {
+		t.Errorf("unexpected entries %s", got)
+	}
+	if len(sub.StringVar) != 1 || sub.StringVar["pub"] == nil {
+		t.Errorf("expected only the pub macro, got %v", sub.StringVar)
+	}
+}
+
+func TestUnusedStrings(t *testing.T) {
+	bib := MustParse(t, `
+@string{acm = {ACM}}
+@string{press = {Press}}
+@string{ieee = {IEEE}}
+@book{a, publisher = acm # " " # press}
+`)
+	if got := bib.UnusedStrings(); len(got) != 1 || got[0] != "ieee" {
+		t.Errorf("got unused strings %v, expected [ieee]", got)
+	}
+}
+
+func TestSplitByType(t *testing.T) {
+	bib := MustParse(t, `
+@string{ieee = {IEEE Transactions}}
+@string{pub = {Publisher}}
+@article{a, journal = ieee, title = {A}}
+@article{b, title = {B}}
+@inproceedings{paper, crossref = {proc}, title = {Paper}}
+@proceedings{proc, publisher = pub, title = {Proceedings}}
+@book{c, publisher = pub, title = {C}}
+`)
+	split := bib.SplitByType()
+	expect := map[string]string{
+		"article":       "a,b",
+		"inproceedings": "paper,proc",
+		"proceedings":   "proc",
+		"book":          "c",
+	}
+	if len(split) != len(expect) {
+		t.Fatalf("got %d types, expected %d", len(split), len(expect))
+	}
+	for typ, keys := range expect {
+		sub, ok := split[typ]
+		if !ok {
+			t.Fatalf("missing type %s", typ)
+		}
+		AssertOrder(t, sub.Entries, keys)
+
+		// Each sub-bibliography must resolve its references on its own.
+		for _, entry := range sub.Entries {
+			for _, key := range parentKeys(entry) {
+				if sub.ByKey(key) == nil {
+					t.Errorf("%s: parent %s of %s missing", typ, key, entry.CiteName)
+				}
+			}
+			for _, value := range entry.Fields {
+				walkStringVars(value, func(v *BibVar) {
+					if sub.GetStringVar(v.Key) == nil {
+						t.Errorf("%s: string %s used by %s missing", typ, v.Key, entry.CiteName)
+					}
+				})
+			}
+		}
+	}
+	if len(split["book"].StringVar) != 1 || len(split["article"].StringVar) != 1 {
+		t.Errorf("unexpected string variables")
+	}
+}
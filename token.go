@@ -3,6 +3,8 @@ package bibtex
 import (
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Lexer token.
@@ -13,6 +15,24 @@ const (
 	ILLEGAL Token = iota
 )
 
+// String returns the name of the token, such as ATSIGN.
+func (t Token) String() string {
+	if t == ILLEGAL {
+		return "ILLEGAL"
+	}
+	// Token names follow the three names the parser reserves.
+	if i := int(t) - COMMENT + 3; t >= COMMENT && i < len(bibtexToknames) {
+		return bibtexToknames[i]
+	}
+	return fmt.Sprintf("Token(%d)", int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the token as its
+// name.
+func (t Token) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
 var eof = rune(0)
 
 // TokenPos is a pair of coordinate to identify start of token.
@@ -41,6 +61,18 @@ func isAlphanum(ch rune) bool {
 	return isAlpha(ch) || isDigit(ch)
 }
 
+// isIdentStart returns true if ch can begin a bare identifier. Tools such as
+// JabRef use field names with a leading underscore, like __markedentry.
+func isIdentStart(ch rune) bool {
+	return isAlphanum(ch) || isBareLetter(ch) || ch == '_'
+}
+
+// isBareLetter returns true if ch is a non-ASCII letter, which may appear in
+// bare identifiers such as the citation key Müller2020.
+func isBareLetter(ch rune) bool {
+	return ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
 func isBareSymbol(ch rune) bool {
 	return strings.ContainsRune("-_:./+", ch)
 }
@@ -53,3 +85,9 @@ func isSymbol(ch rune) bool {
 func isOpenQuote(ch rune) bool {
 	return ch == '{' || ch == '"'
 }
+
+// isEscapable returns true if ch is a delimiter that can be escaped with a
+// backslash inside a value.
+func isEscapable(ch rune) bool {
+	return strings.ContainsRune("{}\"#@%\\", ch)
+}
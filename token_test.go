@@ -0,0 +1,44 @@
+package bibtex
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTokenString(t *testing.T) {
+	cases := map[Token]string{
+		ILLEGAL:   "ILLEGAL",
+		COMMENT:   "COMMENT",
+		STRING:    "STRING",
+		PREAMBLE:  "PREAMBLE",
+		ATSIGN:    "ATSIGN",
+		COLON:     "COLON",
+		EQUAL:     "EQUAL",
+		COMMA:     "COMMA",
+		POUND:     "POUND",
+		LBRACE:    "LBRACE",
+		RBRACE:    "RBRACE",
+		DQUOTE:    "DQUOTE",
+		LPAREN:    "LPAREN",
+		RPAREN:    "RPAREN",
+		BAREIDENT: "BAREIDENT",
+		IDENT:     "IDENT",
+		42:        "Token(42)",
+		IDENT + 1: "Token(57361)",
+	}
+	for tok, name := range cases {
+		if got := tok.String(); got != name {
+			t.Errorf("got %q, expected %q", got, name)
+		}
+	}
+}
+
+func TestTokenMarshalText(t *testing.T) {
+	b, err := json.Marshal([]Token{ATSIGN, IDENT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["ATSIGN","IDENT"]` {
+		t.Errorf("got %s", b)
+	}
+}
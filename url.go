@@ -0,0 +1,40 @@
+package bibtex
+
+import (
+	"strings"
+	"time"
+)
+
+// urlDateLayouts are the accepted formats of the urldate field.
+var urlDateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// URL returns the url field of the entry, with surrounding whitespace and
+// braces removed.
+func (entry *BibEntry) URL() string {
+	return strings.TrimSpace(strings.Trim(fieldString(entry, "url"), "{}"))
+}
+
+// URLDate returns the date the url of the entry was accessed, parsed from an
+// ISO 8601 urldate field such as 2023-01-15.
+func (entry *BibEntry) URLDate() (time.Time, bool) {
+	value := fieldString(entry, "urldate")
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range urlDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func checkURLDate(v *Validator, entry *BibEntry) []*ValidationError {
+	if _, ok := entry.Fields["urldate"]; !ok {
+		return nil
+	}
+	if _, ok := entry.URLDate(); !ok {
+		return []*ValidationError{fieldError(entry, "urldate", SeverityError, "malformed urldate")}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package bibtex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestURL(t *testing.T) {
+	entry := MustParse(t, `@online{a, url = { {https://example.com/page} }}`).Entries[0]
+	if got := entry.URL(); got != "https://example.com/page" {
+		t.Errorf("got url %q", got)
+	}
+}
+
+func TestURLDate(t *testing.T) {
+	entry := MustParse(t, `@online{a, url = {https://example.com}, urldate = {2023-01-15}}`).Entries[0]
+	date, ok := entry.URLDate()
+	if !ok || !date.Equal(time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got urldate %v, %v", date, ok)
+	}
+	if errs := entry.Validate(); len(errs) != 0 {
+		t.Errorf("unexpected validation errors %v", errs)
+	}
+}
+
+func TestURLDateMalformed(t *testing.T) {
+	entry := MustParse(t, `@online{a, url = {https://example.com}, urldate = {15/01/2023}}`).Entries[0]
+	if _, ok := entry.URLDate(); ok {
+		t.Errorf("expected malformed urldate")
+	}
+	errs := entry.Validate()
+	if len(errs) != 1 || errs[0].Field != "urldate" || errs[0].Value != "15/01/2023" {
+		t.Errorf("unexpected validation errors %v", errs)
+	}
+}
@@ -0,0 +1,284 @@
+package bibtex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Severity is the seriousness of a validation issue.
+type Severity int
+
+const (
+	// SeverityError is a definite problem with an entry.
+	SeverityError Severity = iota
+	// SeverityWarning is an advisory issue that may be intentional.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// ValidationError is an issue found in an entry by Validate.
+type ValidationError struct {
+	Key      string // Citation key of the entry.
+	Field    string // Field the issue concerns, if any.
+	Value    string // Offending value, if any.
+	Severity Severity
+	Message  string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Value != "" {
+		return fmt.Sprintf("%s: %s: %s: %q", e.Severity, e.Key, e.Message, e.Value)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Severity, e.Key, e.Message)
+}
+
+// Validator holds configuration for validating entries. The zero value is
+// ready to use.
+type Validator struct {
+	// YearWords are non-numeric values accepted in the year field, such as
+	// "in press" or "forthcoming". They are compared case-insensitively.
+	YearWords []string
+
+	// RequiredFields checks that entries have the fields required by
+	// standard BibTeX styles for their type.
+	RequiredFields bool
+}
+
+// entryCheck checks an entry and returns any issues found.
+type entryCheck func(v *Validator, entry *BibEntry) []*ValidationError
+
+// entryChecks are the checks applied by Validate.
+var entryChecks = []entryCheck{
+	checkDOI,
+	checkISBN,
+	checkYear,
+	checkURLDate,
+	checkKey,
+	checkType,
+	checkRequired,
+}
+
+// Validate checks all entries for common data errors.
+func (bib *BibTex) Validate() []*ValidationError {
+	return (&Validator{}).Validate(bib)
+}
+
+// Validate checks the entry for common data errors.
+func (entry *BibEntry) Validate() []*ValidationError {
+	return (&Validator{}).ValidateEntry(entry)
+}
+
+// Validate checks all entries in bib.
+func (v *Validator) Validate(bib *BibTex) []*ValidationError {
+	var errs []*ValidationError
+	for _, entry := range bib.Entries {
+		errs = append(errs, v.ValidateEntry(entry)...)
+	}
+	return errs
+}
+
+// ValidateEntry checks a single entry.
+func (v *Validator) ValidateEntry(entry *BibEntry) []*ValidationError {
+	var errs []*ValidationError
+	for _, check := range entryChecks {
+		errs = append(errs, check(v, entry)...)
+	}
+	return errs
+}
+
+// fieldError builds an error for the given field of entry.
+func fieldError(entry *BibEntry, field string, severity Severity, message string) *ValidationError {
+	return &ValidationError{
+		Key:      entry.CiteName,
+		Field:    field,
+		Value:    entry.Fields[field].String(),
+		Severity: severity,
+		Message:  message,
+	}
+}
+
+var doiRe = regexp.MustCompile(`^10\.\d+(\.\d+)*/\S+$`)
+
+// ValidDOI reports whether s is a syntactically valid DOI, such as
+// 10.1000/182.
+func ValidDOI(s string) bool {
+	return doiRe.MatchString(s)
+}
+
+// ValidISBN reports whether s is an ISBN-10 or ISBN-13 with a correct check
+// digit. Hyphens and spaces are ignored.
+func ValidISBN(s string) bool {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(s)
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, ch := range digits {
+			var d int
+			switch {
+			case '0' <= ch && ch <= '9':
+				d = int(ch - '0')
+			case (ch == 'X' || ch == 'x') && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += (10 - i) * d
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, ch := range digits {
+			if !isDigit(ch) {
+				return false
+			}
+			d := int(ch - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	return false
+}
+
+// unsafeKeyChars are characters that break \cite when used in a citation key.
+const unsafeKeyChars = ",{}()%#~\\\"'="
+
+// isUnsafeKeyChar reports whether ch may not be used in a citation key.
+func isUnsafeKeyChar(ch rune) bool {
+	return unicode.IsSpace(ch) || strings.ContainsRune(unsafeKeyChars, ch)
+}
+
+// ValidKey reports whether s can be used as a citation key in LaTeX. Keys must
+// be non-empty and free of whitespace, commas, braces and other characters
+// with special meaning to BibTeX or LaTeX, such as % and #.
+func ValidKey(s string) bool {
+	return s != "" && strings.IndexFunc(s, isUnsafeKeyChar) < 0
+}
+
+// SanitizeKey returns a variant of s that is a valid citation key, replacing
+// each run of unsafe characters with an underscore.
+func SanitizeKey(s string) string {
+	var buf strings.Builder
+	unsafe := false
+	for _, ch := range s {
+		if isUnsafeKeyChar(ch) {
+			unsafe = true
+			continue
+		}
+		if unsafe && buf.Len() > 0 {
+			buf.WriteRune('_')
+		}
+		unsafe = false
+		buf.WriteRune(ch)
+	}
+	if buf.Len() == 0 {
+		return "key"
+	}
+	return buf.String()
+}
+
+func checkKey(v *Validator, entry *BibEntry) []*ValidationError {
+	if !ValidKey(entry.CiteName) {
+		return []*ValidationError{{
+			Key:      entry.CiteName,
+			Value:    entry.CiteName,
+			Severity: SeverityError,
+			Message:  "citation key is not LaTeX-safe",
+		}}
+	}
+	return nil
+}
+
+// typeHints suggest an entry type from a field that is characteristic of it.
+// An entry with the field and none of the types is likely mistyped.
+var typeHints = []struct {
+	Field   string
+	Types   []string
+	Suggest string
+}{
+	{"journal", []string{"article", "periodical"}, "article"},
+	{"journaltitle", []string{"article", "periodical"}, "article"},
+	{"booktitle", []string{"inproceedings", "incollection", "inbook", "conference", "inreference", "suppbook", "suppcollection", "bookinbook"}, "inproceedings"},
+	{"school", []string{"phdthesis", "mastersthesis", "thesis"}, "phdthesis"},
+	{"institution", []string{"techreport", "report", "thesis", "phdthesis", "mastersthesis"}, "techreport"},
+}
+
+func checkType(v *Validator, entry *BibEntry) []*ValidationError {
+	for _, hint := range typeHints {
+		if _, ok := entry.Fields[hint.Field]; !ok || hasType(entry, hint.Types) {
+			continue
+		}
+		return []*ValidationError{{
+			Key:      entry.CiteName,
+			Value:    entry.Type,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("has %s, consider @%s", hint.Field, hint.Suggest),
+		}}
+	}
+	_, journal := entry.Fields["journal"]
+	_, journaltitle := entry.Fields["journaltitle"]
+	if _, publisher := entry.Fields["publisher"]; entry.Type == "article" && publisher && !journal && !journaltitle {
+		return []*ValidationError{{
+			Key:      entry.CiteName,
+			Value:    entry.Type,
+			Severity: SeverityWarning,
+			Message:  "has publisher but no journal, consider @book",
+		}}
+	}
+	return nil
+}
+
+// hasType reports whether entry is of one of the given types.
+func hasType(entry *BibEntry, types []string) bool {
+	for _, t := range types {
+		if entry.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func checkDOI(v *Validator, entry *BibEntry) []*ValidationError {
+	if doi, ok := entry.Fields["doi"]; ok && !ValidDOI(strings.TrimSpace(doi.String())) {
+		return []*ValidationError{fieldError(entry, "doi", SeverityError, "malformed doi")}
+	}
+	return nil
+}
+
+func checkISBN(v *Validator, entry *BibEntry) []*ValidationError {
+	if isbn, ok := entry.Fields["isbn"]; ok && !ValidISBN(strings.TrimSpace(isbn.String())) {
+		return []*ValidationError{fieldError(entry, "isbn", SeverityError, "malformed isbn")}
+	}
+	return nil
+}
+
+// checkYear checks the year is a four digit number from 1000 to two years
+// from now.
+func checkYear(v *Validator, entry *BibEntry) []*ValidationError {
+	value, ok := entry.Fields["year"]
+	if !ok {
+		return nil
+	}
+	year := strings.TrimSpace(value.String())
+	for _, word := range v.YearWords {
+		if strings.EqualFold(year, word) {
+			return nil
+		}
+	}
+	if n, err := strconv.Atoi(year); err != nil || len(year) != 4 || n < 1000 || n > time.Now().Year()+2 {
+		return []*ValidationError{fieldError(entry, "year", SeverityWarning, "implausible year")}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package bibtex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidDOI(t *testing.T) {
+	for _, doi := range []string{"10.1000/182", "10.1038/nphys1170", "10.1002/(SICI)1097-4571(199806)49:8<693::AID-ASI3>3.0.CO;2-0"} {
+		if !ValidDOI(doi) {
+			t.Errorf("expected %q to be valid", doi)
+		}
+	}
+	for _, doi := range []string{"", "10.1000", "11.1000/182", "doi:10.1000/182", "10.abc/182", "10.1000/18 2"} {
+		if ValidDOI(doi) {
+			t.Errorf("expected %q to be invalid", doi)
+		}
+	}
+}
+
+func TestValidISBN(t *testing.T) {
+	for _, isbn := range []string{"0-306-40615-2", "978-0-306-40615-7", "080442957X", "9780306406157"} {
+		if !ValidISBN(isbn) {
+			t.Errorf("expected %q to be valid", isbn)
+		}
+	}
+	for _, isbn := range []string{"0-306-40615-3", "978-0-306-40615-8", "12345", "X123456789"} {
+		if ValidISBN(isbn) {
+			t.Errorf("expected %q to be invalid", isbn)
+		}
+	}
+}
+
+func TestValidateDOIISBN(t *testing.T) {
+	bib := MustParse(t, `
+@book{good, doi = {10.1000/182}, isbn = {978-0-306-40615-7}}
+@book{baddoi, doi = {doi.org/182}}
+@book{badisbn, isbn = {978-0-306-40615-8}}
+`)
+	errs := bib.Validate()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if e := errs[0]; e.Key != "baddoi" || e.Field != "doi" || e.Value != "doi.org/182" {
+		t.Errorf("unexpected error %v", e)
+	}
+	if e := errs[1]; e.Key != "badisbn" || e.Field != "isbn" || e.Value != "978-0-306-40615-8" {
+		t.Errorf("unexpected error %v", e)
+	}
+}
+
+func TestValidateYear(t *testing.T) {
+	bib := MustParse(t, `
+@misc{good, year = 2020}
+@misc{typo, year = 20200}
+@misc{short, year = {202}}
+@misc{press, year = {Forthcoming}}
+@misc{zero, year = {0000}}
+@misc{early, year = {0123}}
+@misc{old, year = 1000}
+`)
+	var keys []string
+	for _, e := range bib.Validate() {
+		if e.Field != "year" || e.Severity != SeverityWarning {
+			t.Errorf("unexpected issue %v", e)
+		}
+		keys = append(keys, e.Key)
+	}
+	if got := strings.Join(keys, ","); got != "typo,short,press,zero,early" {
+		t.Errorf("got issues for %s", got)
+	}
+
+	v := &Validator{YearWords: []string{"in press", "forthcoming"}}
+	keys = nil
+	for _, e := range v.Validate(bib) {
+		keys = append(keys, e.Key)
+	}
+	if got := strings.Join(keys, ","); got != "typo,short,zero,early" {
+		t.Errorf("got issues for %s", got)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for _, key := range []string{"knuth1984", "Doe:2020a", "smith-jones_2001", "müller99"} {
+		if !ValidKey(key) {
+			t.Errorf("expected %q to be valid", key)
+		}
+	}
+	for _, key := range []string{"", "smith 2020", "c#sharp", "a,b", "{key}", "100%"} {
+		if ValidKey(key) {
+			t.Errorf("expected %q to be invalid", key)
+		}
+	}
+}
+
+func TestSanitizeKey(t *testing.T) {
+	cases := map[string]string{
+		"smith 2020":    "smith_2020",
+		"c#sharp":       "c_sharp",
+		" {Title}, 99 ": "Title_99",
+		"%#":            "key",
+	}
+	for key, expect := range cases {
+		if got := SanitizeKey(key); got != expect || !ValidKey(got) {
+			t.Errorf("SanitizeKey(%q) = %q; expected %q", key, got, expect)
+		}
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"smith 2020", "c#sharp"} {
+		entry := &BibEntry{Type: "misc", CiteName: key}
+		errs := entry.Validate()
+		if len(errs) != 1 || errs[0].Value != key || errs[0].Severity != SeverityError {
+			t.Errorf("%q: unexpected validation errors %v", key, errs)
+		}
+	}
+}
+
+func TestValidateType(t *testing.T) {
+	cases := []struct {
+		Src     string
+		Message string
+	}{
+		{`@misc{a, journal = {J}, volume = 1, pages = {1--10}}`, "has journal, consider @article"},
+		{`@book{a, title = {T}, booktitle = {Proceedings}}`, "has booktitle, consider @inproceedings"},
+		{`@article{a, title = {T}, publisher = {P}}`, "has publisher but no journal, consider @book"},
+		{`@article{a, journal = {J}, publisher = {P}}`, ""},
+	}
+	for _, c := range cases {
+		entry := MustParse(t, c.Src).Entries[0]
+		errs := entry.Validate()
+		if c.Message == "" {
+			if len(errs) != 0 {
+				t.Errorf("%s: unexpected validation errors %v", c.Src, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 || errs[0].Message != c.Message || errs[0].Severity != SeverityWarning {
+			t.Errorf("%s: got validation errors %v, expected %q", c.Src, errs, c.Message)
+		}
+	}
+}